@@ -0,0 +1,33 @@
+package pinboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ReadBackup reads posts from a JSON backup, as downloaded from Pinboard's settings
+// page. The posts can be compared to the live account with DiffPosts and restored
+// with PostsAdd.
+func ReadBackup(r io.Reader) ([]Post, error) {
+	var wire []jsonPost
+	err := json.NewDecoder(r).Decode(&wire)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse Pinboard backup: %w", err)
+	}
+
+	posts := make([]Post, 0, len(wire))
+	for _, jp := range wire {
+		posts = append(posts, jp.post())
+	}
+	return posts, nil
+}
+
+// WriteBackup writes posts to w in the format of Pinboard's JSON backups.
+func WriteBackup(w io.Writer, posts []Post) error {
+	wire := make([]jsonPost, 0, len(posts))
+	for _, pp := range posts {
+		wire = append(wire, newJSONPost(pp))
+	}
+	return json.NewEncoder(w).Encode(wire)
+}
@@ -0,0 +1,160 @@
+// Package bookmarkhtml reads and writes posts in the Netscape Bookmark File format,
+// the HTML format browsers and Pinboard use to import and export bookmarks.
+//
+// Each post is written as a link carrying the post's date (ADD_DATE), tags (TAGS,
+// comma separated), privacy (PRIVATE) and read-indicator (TOREAD), followed by its
+// extended description in a DD element. The Pinboard-specific HASH and META
+// attributes keep the rest of the post, so a file written by Encode decodes back
+// into the same posts. PRIVATE and TOREAD are only written for posts that set
+// Shared and ToRead, and links without them leave those fields unset, so the
+// account's defaults apply when adding them.
+package bookmarkhtml
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	pinboard "github.com/zoni/go-pinboard"
+)
+
+const header = `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<META HTTP-EQUIV="Content-Type" CONTENT="text/html; charset=UTF-8">
+<TITLE>Pinboard Bookmarks</TITLE>
+<H1>Pinboard Bookmarks</H1>
+<DL><p>
+`
+
+const footer = "</DL><p>\n"
+
+// Encode writes posts to w as a bookmark file.
+func Encode(w io.Writer, posts []pinboard.Post) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(header)
+	for _, pp := range posts {
+		fmt.Fprintf(bw, `<DT><A HREF="%s"`, html.EscapeString(pp.Url))
+		if !pp.Date.IsZero() {
+			fmt.Fprintf(bw, ` ADD_DATE="%d"`, pp.Date.Unix())
+		}
+		if pp.Shared.IsSet() {
+			fmt.Fprintf(bw, ` PRIVATE="%s"`, flag(pp.Shared == pinboard.No))
+		}
+		if pp.ToRead.IsSet() {
+			fmt.Fprintf(bw, ` TOREAD="%s"`, flag(pp.ToRead == pinboard.Yes))
+		}
+		fmt.Fprintf(bw, ` TAGS="%s"`, html.EscapeString(strings.Join(pp.Tags, ",")))
+		if len(pp.Hash) > 0 {
+			fmt.Fprintf(bw, ` HASH="%s"`, html.EscapeString(pp.Hash))
+		}
+		if len(pp.Meta) > 0 {
+			fmt.Fprintf(bw, ` META="%s"`, html.EscapeString(pp.Meta))
+		}
+		fmt.Fprintf(bw, ">%s</A>\n", html.EscapeString(pp.Description))
+		if len(pp.Extended) > 0 {
+			fmt.Fprintf(bw, "<DD>%s\n", html.EscapeString(pp.Extended))
+		}
+	}
+	bw.WriteString(footer)
+	return bw.Flush()
+}
+
+var (
+	dtRe   = regexp.MustCompile(`(?i)<DT>`)
+	linkRe = regexp.MustCompile(`(?is)^\s*<A\s([^>]*)>(.*?)</A>`)
+	ddRe   = regexp.MustCompile(`(?is)^\s*<DD>([^<]*)`)
+	attrRe = regexp.MustCompile(`([A-Za-z_]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+)
+
+// Decode reads the links in a bookmark file into posts, ready for PostsAdd. Folders
+// are flattened and links without an HREF are skipped. Posts are returned in the
+// order they appear in the file.
+func Decode(r io.Reader) ([]pinboard.Post, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	doc := string(b)
+
+	var posts []pinboard.Post
+	starts := dtRe.FindAllStringIndex(doc, -1)
+	for n, s := range starts {
+		end := len(doc)
+		if n+1 < len(starts) {
+			end = starts[n+1][0]
+		}
+		item := doc[s[1]:end]
+
+		m := linkRe.FindStringSubmatchIndex(item)
+		if m == nil {
+			continue
+		}
+		pp, err := parseLink(item[m[2]:m[3]], item[m[4]:m[5]])
+		if err != nil {
+			return nil, err
+		}
+		if len(pp.Url) < 1 {
+			continue
+		}
+
+		if dd := ddRe.FindStringSubmatch(item[m[1]:]); dd != nil {
+			pp.Extended = html.UnescapeString(trimDD(dd[1]))
+		}
+		posts = append(posts, pp)
+	}
+	return posts, nil
+}
+
+// trimDD removes the line break ending a DD element's text, and the indentation
+// of the following line.
+func trimDD(s string) string {
+	if i := strings.LastIndex(s, "\n"); i >= 0 && len(strings.TrimSpace(s[i:])) == 0 {
+		s = s[:i]
+	}
+	return strings.TrimSuffix(s, "\r")
+}
+
+// flag formats a boolean attribute.
+func flag(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+func parseLink(attrs, title string) (pinboard.Post, error) {
+	pp := pinboard.Post{Description: html.UnescapeString(strings.TrimSpace(title))}
+	for _, a := range attrRe.FindAllStringSubmatch(attrs, -1) {
+		v := html.UnescapeString(a[2] + a[3] + a[4])
+		switch strings.ToUpper(a[1]) {
+		case "HREF":
+			pp.Url = v
+		case "ADD_DATE":
+			sec, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return pp, fmt.Errorf("Invalid ADD_DATE %q for %s: %w", v, pp.Url, err)
+			}
+			pp.Date = time.Unix(sec, 0).UTC()
+		case "PRIVATE":
+			pp.Shared = pinboard.NewYesNo(v != "1")
+		case "TOREAD":
+			pp.ToRead = pinboard.NewYesNo(v == "1")
+		case "TAGS":
+			for _, t := range strings.Split(v, ",") {
+				if t = strings.TrimSpace(t); len(t) > 0 {
+					pp.Tags = append(pp.Tags, t)
+				}
+			}
+		case "HASH":
+			pp.Hash = v
+		case "META":
+			pp.Meta = v
+		}
+	}
+	return pp, nil
+}
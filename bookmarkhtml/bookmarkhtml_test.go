@@ -0,0 +1,71 @@
+package bookmarkhtml
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	pinboard "github.com/zoni/go-pinboard"
+)
+
+func TestRoundTrip(t *testing.T) {
+	want := []pinboard.Post{
+		{
+			Url:         "https://example.com/?a=1&b=2",
+			Description: `Tom & Jerry's "<best>" episodes`,
+			Hash:        "c984d06aafbecf6bc55569f964148ea3",
+			Tags:        []string{"cartoons", ".private"},
+			Extended:    "Line one\nLine <two> & three",
+			Date:        time.Date(2011, time.March, 25, 14, 49, 56, 0, time.UTC),
+			Shared:      pinboard.No,
+			Meta:        "92959a96fd69146c5fe7cbde6e5720f2",
+		},
+		{
+			Url:         "https://example.org/",
+			Description: "Example",
+			Date:        time.Date(2012, time.January, 1, 0, 0, 0, 0, time.UTC),
+			Shared:      pinboard.Yes,
+		},
+	}
+
+	var buf bytes.Buffer
+	err := Encode(&buf, want)
+	if err != nil {
+		t.Fatalf("Error from Encode: %v", err)
+	}
+	got, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("Error from Decode: %v", err)
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Posts did not round trip.\nWant %#v\nGot  %#v", want, got)
+	}
+}
+
+func TestDecodeBrowserExport(t *testing.T) {
+	doc := `<!DOCTYPE NETSCAPE-Bookmark-file-1>
+<TITLE>Bookmarks</TITLE>
+<DL><p>
+    <DT><H3 ADD_DATE="1300000000">Folder</H3>
+    <DL><p>
+        <dt><a href="https://golang.org/" add_date="1300000000" tags="go">The Go Programming Language</a>
+        <DD>Go's home
+    </DL><p>
+    <DT><A HREF='https://example.com/'>Example</A>
+</DL><p>`
+	got, err := Decode(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("Error from Decode: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Wanted 2 posts, Got %v", got)
+	}
+	if got[0].Url != "https://golang.org/" || got[0].Extended != "Go's home" || got[0].Tags[0] != "go" || got[0].Date.Unix() != 1300000000 {
+		t.Errorf("Unexpected first post %#v", got[0])
+	}
+	if got[1].Url != "https://example.com/" || got[1].Description != "Example" {
+		t.Errorf("Unexpected second post %#v", got[1])
+	}
+}
@@ -0,0 +1,176 @@
+package pinboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// A PostSelector picks the posts a bulk tag operation applies to. A post must
+// satisfy every field that is set; the zero PostSelector selects every post in the
+// account. Tags, From and To are sent to the API as a PostsAllFilter, Host and Match
+// are applied locally.
+type PostSelector struct {
+	// Tags selects posts tagged with all of the given tags (up to 3).
+	Tags []string
+
+	// From and To select posts saved within the given date range.
+	From time.Time
+	To   time.Time
+
+	// Host selects posts whose URL is on the given host or one of its subdomains,
+	// so "example.com" also selects posts on "www.example.com".
+	Host string
+
+	// Match, if set, selects posts for which it returns true.
+	Match func(Post) bool
+}
+
+func (s PostSelector) matches(pp Post) bool {
+	if len(s.Host) > 0 {
+		u, err := url.Parse(pp.Url)
+		if err != nil {
+			return false
+		}
+		host := strings.ToLower(u.Hostname())
+		want := strings.ToLower(s.Host)
+		if host != want && !strings.HasSuffix(host, "."+want) {
+			return false
+		}
+	}
+	return s.Match == nil || s.Match(pp)
+}
+
+// A TagEdit describes a change to the tags of a post. Replace is applied first,
+// then Remove, then Add. Tags are compared case-insensitively, as Pinboard does.
+type TagEdit struct {
+	// Add holds tags to add to every post that doesn't have them yet.
+	Add []string
+
+	// Remove holds tags to remove from every post.
+	Remove []string
+
+	// Replace maps old tag names to new ones. Unlike TagsRename, only the
+	// selected posts are changed.
+	Replace map[string]string
+}
+
+func (e TagEdit) apply(tags []string) []string {
+	has := func(tags []string, t string) bool {
+		for _, v := range tags {
+			if strings.EqualFold(v, t) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var out []string
+	for _, t := range tags {
+		for old, new := range e.Replace {
+			if strings.EqualFold(t, old) {
+				t = new
+				break
+			}
+		}
+		if !has(e.Remove, t) && !has(out, t) {
+			out = append(out, t)
+		}
+	}
+	for _, t := range e.Add {
+		if !has(out, t) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// BulkOptions configure BulkTags.
+type BulkOptions struct {
+	// DryRun makes BulkTags only report the changes it would make.
+	DryRun bool
+
+	// Interval is the minimum time between two posts written by BulkTags. If
+	// zero, DefaultRateLimit.Interval is used.
+	Interval time.Duration
+
+	// Progress, if set, is called after every written post with the number of
+	// posts written so far.
+	Progress func(done, total int)
+}
+
+// A TagChange is the change BulkTags made, or would make, to a single post.
+type TagChange struct {
+	Post   Post
+	Before []string
+	After  []string
+
+	// Err is set if the post could not be written.
+	Err error
+}
+
+// String formats the change as "url: before -> after".
+func (c TagChange) String() string {
+	s := fmt.Sprintf("%s: %s -> %s", c.Post.Url, strings.Join(c.Before, " "), strings.Join(c.After, " "))
+	if c.Err != nil {
+		s += fmt.Sprintf(" (%v)", c.Err)
+	}
+	return s
+}
+
+// BulkTags applies edit to the tags of every post selected by sel. The selected
+// posts are read with PostsAll and every post whose tags change is added back with
+// PostsAdd, replacing it while keeping its other fields. The returned changes list
+// each changed post with its tags before and after the edit; with opts.DryRun set
+// nothing is written, so they serve as a preview.
+//
+// A post that fails to be written has its change's Err set and the operation
+// continues. BulkTags stops and returns an error when ctx is done or the
+// credentials are rejected.
+func BulkTags(ctx context.Context, c Client, sel PostSelector, edit TagEdit, opts BulkOptions) ([]TagChange, error) {
+	var changes []TagChange
+	apf := PostsAllFilter{Tags: sel.Tags, From: sel.From, To: sel.To}
+	err := c.PostsAllEachContext(ctx, apf, func(pp Post) error {
+		if !sel.matches(pp) {
+			return nil
+		}
+		after := edit.apply(pp.Tags)
+		if strings.Join(after, " ") != strings.Join(pp.Tags, " ") {
+			changes = append(changes, TagChange{Post: pp, Before: pp.Tags, After: after})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to select posts: %w", err)
+	}
+	if opts.DryRun {
+		return changes, nil
+	}
+
+	limiter := newRateLimiter(RateLimit{Interval: opts.Interval})
+	for n := range changes {
+		err := limiter.wait(ctx, "posts/add")
+		if err != nil {
+			return changes, err
+		}
+
+		pp := changes[n].Post
+		pp.Tags = changes[n].After
+		err = c.PostsAddContext(ctx, pp, false)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, ErrAuthFailed) {
+				return changes, err
+			}
+			changes[n].Err = err
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(n+1, len(changes))
+		}
+	}
+
+	return changes, nil
+}
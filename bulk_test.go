@@ -0,0 +1,63 @@
+package pinboard_test
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	pinboard "github.com/zoni/go-pinboard"
+	"github.com/zoni/go-pinboard/pinboardtest"
+)
+
+func TestBulkTags(t *testing.T) {
+	date := time.Date(2011, time.March, 25, 14, 49, 56, 0, time.UTC)
+	s := pinboardtest.NewServer(pinboardtest.WithPosts(
+		pinboard.Post{Url: "https://www.example.com/1", Description: "One", Tags: []string{"go", "old"}, Date: date},
+		pinboard.Post{Url: "https://example.com/2", Description: "Two", Tags: []string{"go", "New"}, Date: date.Add(-time.Hour), Shared: pinboard.Yes},
+		pinboard.Post{Url: "https://example.org/3", Description: "Three", Tags: []string{"go", "old"}, Date: date},
+		pinboard.Post{Url: "https://example.com/4", Description: "Four", Tags: []string{"rust", "old"}, Date: date},
+	))
+	defer s.Close()
+	p := s.Client()
+
+	sel := pinboard.PostSelector{Tags: []string{"go"}, Host: "example.com"}
+	edit := pinboard.TagEdit{Add: []string{"new"}, Remove: []string{"go"}, Replace: map[string]string{"old": "legacy"}}
+	want := map[string]string{
+		"https://www.example.com/1": "legacy new",
+		"https://example.com/2":     "New",
+	}
+
+	changes, err := pinboard.BulkTags(context.Background(), p, sel, edit, pinboard.BulkOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Error from dry-run BulkTags: %v", err)
+	}
+	got := map[string]string{}
+	for _, c := range changes {
+		got[c.Post.Url] = strings.Join(c.After, " ")
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Wanted changes %v, Got %v", want, got)
+	}
+	if n := s.Calls("posts/add"); n != 0 {
+		t.Errorf("Wanted no posts/add calls from dry run, Got %d", n)
+	}
+
+	_, err = pinboard.BulkTags(context.Background(), p, sel, edit, pinboard.BulkOptions{Interval: time.Millisecond})
+	if err != nil {
+		t.Fatalf("Error from BulkTags: %v", err)
+	}
+	got = map[string]string{}
+	for _, pp := range s.Posts() {
+		got[pp.Url] = strings.Join(pp.Tags, " ")
+		if pp.Url == "https://example.com/2" && (!pp.Date.Equal(date.Add(-time.Hour)) || pp.Shared != pinboard.Yes) {
+			t.Errorf("Wanted other fields to be kept, Got %v", pp)
+		}
+	}
+	want["https://example.org/3"] = "go old"
+	want["https://example.com/4"] = "rust old"
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Wanted tags %v, Got %v", want, got)
+	}
+}
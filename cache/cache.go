@@ -0,0 +1,274 @@
+// Package cache provides a pinboard.Client decorator that caches the results of
+// read methods until the account changes.
+//
+// Pinboard's posts/update method returns the time the account was last changed,
+// and read methods return identical data until that time moves. The caching
+// Client checks posts/update at most once per freshness window and drops every
+// cached result when the update time has advanced.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	pinboard "github.com/zoni/go-pinboard"
+)
+
+// updatedKey is the Store key holding the update time cached results belong to.
+const updatedKey = "posts/update"
+
+// A Client caches the results of PostsGet, PostsRecent, PostsDates, PostsAll and
+// TagsGet from the Client it wraps. Write methods pass through and invalidate the
+// cache. All other methods pass through unchanged.
+type Client struct {
+	pinboard.Client
+
+	freshness time.Duration
+	store     Store
+
+	mu      sync.Mutex
+	checked time.Time
+}
+
+var _ pinboard.Client = (*Client)(nil)
+
+// New returns a Client caching results from c in store. The account's update
+// time is re-checked when more than freshness has passed since the last check,
+// so results may be up to freshness out of date.
+func New(c pinboard.Client, store Store, freshness time.Duration) *Client {
+	return &Client{
+		Client:    c,
+		freshness: freshness,
+		store:     store,
+	}
+}
+
+// Invalidate drops every cached result.
+func (c *Client) Invalidate() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checked = time.Time{}
+	return c.store.Clear()
+}
+
+// validate clears the store if the account has changed since results were
+// cached.
+func (c *Client) validate(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.checked.IsZero() && time.Since(c.checked) < c.freshness {
+		return nil
+	}
+
+	updated, err := c.Client.PostsUpdatedContext(ctx)
+	if err != nil {
+		return err
+	}
+	return c.setUpdated(updated)
+}
+
+// setUpdated records the account's update time, clearing the store if it moved.
+// Callers must hold c.mu.
+func (c *Client) setUpdated(updated time.Time) error {
+	c.checked = time.Now()
+
+	var cached time.Time
+	if b, ok := c.store.Get(updatedKey); ok {
+		json.Unmarshal(b, &cached)
+	}
+	if cached.Equal(updated) {
+		return nil
+	}
+
+	err := c.store.Clear()
+	if err != nil {
+		return err
+	}
+	// If the update time can't be stored, the next check clears the store
+	// again, which is safe
+	if b, err := json.Marshal(updated); err == nil {
+		c.store.Set(updatedKey, b)
+	}
+	return nil
+}
+
+// cached stores the result of fetch in the value pointed to by v, from the store
+// if possible.
+func (c *Client) cached(ctx context.Context, method string, args interface{}, v interface{}, fetch func() (interface{}, error)) error {
+	err := c.validate(ctx)
+	if err != nil {
+		return err
+	}
+
+	a, err := json.Marshal(args)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%s %s", method, a)
+
+	if b, ok := c.store.Get(key); ok && json.Unmarshal(b, v) == nil {
+		return nil
+	}
+
+	res, err := fetch()
+	if err != nil {
+		return err
+	}
+	reflect.ValueOf(v).Elem().Set(reflect.ValueOf(res))
+
+	// Failing to cache the result, such as on a full disk, doesn't fail the
+	// read; it is fetched again next time
+	if b, err := json.Marshal(res); err == nil {
+		c.store.Set(key, b)
+	}
+	return nil
+}
+
+// PostsUpdated implements pinboard.Client. The result is also used to validate
+// the cache.
+func (c *Client) PostsUpdated() (time.Time, error) {
+	return c.PostsUpdatedContext(context.Background())
+}
+
+// PostsUpdatedContext implements pinboard.Client.
+func (c *Client) PostsUpdatedContext(ctx context.Context) (time.Time, error) {
+	updated, err := c.Client.PostsUpdatedContext(ctx)
+	if err != nil {
+		return updated, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return updated, c.setUpdated(updated)
+}
+
+// PostsGet implements pinboard.Client.
+func (c *Client) PostsGet(pf pinboard.PostsFilter) ([]pinboard.Post, error) {
+	return c.PostsGetContext(context.Background(), pf)
+}
+
+// PostsGetContext implements pinboard.Client.
+func (c *Client) PostsGetContext(ctx context.Context, pf pinboard.PostsFilter) ([]pinboard.Post, error) {
+	var posts []pinboard.Post
+	err := c.cached(ctx, "posts/get", pf, &posts, func() (interface{}, error) {
+		return c.Client.PostsGetContext(ctx, pf)
+	})
+	return posts, err
+}
+
+// PostsRecent implements pinboard.Client.
+func (c *Client) PostsRecent(rpf pinboard.PostsRecentFilter) ([]pinboard.Post, error) {
+	return c.PostsRecentContext(context.Background(), rpf)
+}
+
+// PostsRecentContext implements pinboard.Client.
+func (c *Client) PostsRecentContext(ctx context.Context, rpf pinboard.PostsRecentFilter) ([]pinboard.Post, error) {
+	var posts []pinboard.Post
+	err := c.cached(ctx, "posts/recent", rpf, &posts, func() (interface{}, error) {
+		return c.Client.PostsRecentContext(ctx, rpf)
+	})
+	return posts, err
+}
+
+// PostsDates implements pinboard.Client.
+func (c *Client) PostsDates(tag string) ([]pinboard.PostDate, error) {
+	return c.PostsDatesContext(context.Background(), tag)
+}
+
+// PostsDatesContext implements pinboard.Client.
+func (c *Client) PostsDatesContext(ctx context.Context, tag string) ([]pinboard.PostDate, error) {
+	var dates []pinboard.PostDate
+	err := c.cached(ctx, "posts/dates", tag, &dates, func() (interface{}, error) {
+		return c.Client.PostsDatesContext(ctx, tag)
+	})
+	return dates, err
+}
+
+// PostsAll implements pinboard.Client.
+func (c *Client) PostsAll(apf pinboard.PostsAllFilter) ([]pinboard.Post, error) {
+	return c.PostsAllContext(context.Background(), apf)
+}
+
+// PostsAllContext implements pinboard.Client.
+func (c *Client) PostsAllContext(ctx context.Context, apf pinboard.PostsAllFilter) ([]pinboard.Post, error) {
+	var posts []pinboard.Post
+	err := c.cached(ctx, "posts/all", apf, &posts, func() (interface{}, error) {
+		return c.Client.PostsAllContext(ctx, apf)
+	})
+	return posts, err
+}
+
+// TagsGet implements pinboard.Client.
+func (c *Client) TagsGet() ([]pinboard.Tag, error) {
+	return c.TagsGetContext(context.Background())
+}
+
+// TagsGetContext implements pinboard.Client.
+func (c *Client) TagsGetContext(ctx context.Context) ([]pinboard.Tag, error) {
+	var tags []pinboard.Tag
+	err := c.cached(ctx, "tags/get", nil, &tags, func() (interface{}, error) {
+		return c.Client.TagsGetContext(ctx)
+	})
+	return tags, err
+}
+
+// PostsAdd implements pinboard.Client.
+func (c *Client) PostsAdd(pp pinboard.Post, keep bool) error {
+	return c.PostsAddContext(context.Background(), pp, keep)
+}
+
+// PostsAddContext implements pinboard.Client.
+func (c *Client) PostsAddContext(ctx context.Context, pp pinboard.Post, keep bool) error {
+	defer c.Invalidate()
+	return c.Client.PostsAddContext(ctx, pp, keep)
+}
+
+// PostsUpdate implements pinboard.Client.
+func (c *Client) PostsUpdate(pu string, fn func(*pinboard.Post) error) error {
+	return c.PostsUpdateContext(context.Background(), pu, fn)
+}
+
+// PostsUpdateContext implements pinboard.Client. The post is read from the wrapped
+// Client, never from the cache, so the Meta check sees the current post.
+func (c *Client) PostsUpdateContext(ctx context.Context, pu string, fn func(*pinboard.Post) error) error {
+	defer c.Invalidate()
+	return c.Client.PostsUpdateContext(ctx, pu, fn)
+}
+
+// PostsDelete implements pinboard.Client.
+func (c *Client) PostsDelete(du string) error {
+	return c.PostsDeleteContext(context.Background(), du)
+}
+
+// PostsDeleteContext implements pinboard.Client.
+func (c *Client) PostsDeleteContext(ctx context.Context, du string) error {
+	defer c.Invalidate()
+	return c.Client.PostsDeleteContext(ctx, du)
+}
+
+// TagsDelete implements pinboard.Client.
+func (c *Client) TagsDelete(tag string) error {
+	return c.TagsDeleteContext(context.Background(), tag)
+}
+
+// TagsDeleteContext implements pinboard.Client.
+func (c *Client) TagsDeleteContext(ctx context.Context, tag string) error {
+	defer c.Invalidate()
+	return c.Client.TagsDeleteContext(ctx, tag)
+}
+
+// TagsRename implements pinboard.Client.
+func (c *Client) TagsRename(old, new string) error {
+	return c.TagsRenameContext(context.Background(), old, new)
+}
+
+// TagsRenameContext implements pinboard.Client.
+func (c *Client) TagsRenameContext(ctx context.Context, old, new string) error {
+	defer c.Invalidate()
+	return c.Client.TagsRenameContext(ctx, old, new)
+}
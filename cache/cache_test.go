@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	pinboard "github.com/zoni/go-pinboard"
+	"github.com/zoni/go-pinboard/pinboardtest"
+)
+
+func TestCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := pinboardtest.NewServer(pinboardtest.WithPosts(
+		pinboard.Post{Url: "https://example.com/1", Description: "One", Tags: []string{"a"}},
+	))
+	defer s.Close()
+	p := s.Client()
+
+	for _, newStore := range []func() Store{
+		func() Store { return NewMemoryStore() },
+		func() Store { ds, _ := NewDirStore(dir); return ds },
+	} {
+		c := New(p, newStore(), 0)
+		before := s.Calls("posts/all")
+
+		for i := 0; i < 2; i++ {
+			posts, err := c.PostsAll(pinboard.PostsAllFilter{})
+			if err != nil || len(posts) != 1 || posts[0].Tags[0] != "a" {
+				t.Fatalf("Wanted 1 post tagged a, Got %v (%v)", posts, err)
+			}
+		}
+		if n := s.Calls("posts/all") - before; n != 1 {
+			t.Errorf("Wanted 1 call to posts/all, Got %d", n)
+		}
+
+		// Changes made behind the cache's back are picked up once the
+		// update time moves
+		err = p.PostsDelete("https://example.com/1")
+		if err != nil {
+			t.Fatalf("Error from PostsDelete: %v", err)
+		}
+		posts, err := c.PostsAll(pinboard.PostsAllFilter{})
+		if err != nil || len(posts) != 0 {
+			t.Errorf("Wanted no posts after the account changed, Got %v (%v)", posts, err)
+		}
+
+		// Writes through the cache invalidate it
+		err = c.PostsAdd(pinboard.Post{Url: "https://example.com/1", Description: "One", Tags: []string{"a"}}, false)
+		if err != nil {
+			t.Fatalf("Error from PostsAdd: %v", err)
+		}
+		tags, err := c.TagsGet()
+		if err != nil || len(tags) != 1 {
+			t.Errorf("Wanted 1 tag, Got %v (%v)", tags, err)
+		}
+	}
+}
+
+func TestCacheFreshness(t *testing.T) {
+	s := pinboardtest.NewServer()
+	defer s.Close()
+
+	c := New(s.Client(), NewMemoryStore(), time.Hour)
+	for i := 0; i < 3; i++ {
+		_, err := c.TagsGet()
+		if err != nil {
+			t.Fatalf("Error from TagsGet: %v", err)
+		}
+	}
+	if n := s.Calls("posts/update"); n != 1 {
+		t.Errorf("Wanted 1 call to posts/update within the freshness window, Got %d", n)
+	}
+	if n := s.Calls("tags/get"); n != 1 {
+		t.Errorf("Wanted 1 call to tags/get, Got %d", n)
+	}
+}
+
+// fullStore is a Store that fails every write, like a DirStore on a full disk.
+type fullStore struct {
+	*MemoryStore
+}
+
+func (fullStore) Set(key string, value []byte) error {
+	return errors.New("no space left on device")
+}
+
+func TestCacheStoreFailure(t *testing.T) {
+	s := pinboardtest.NewServer(pinboardtest.WithPosts(
+		pinboard.Post{Url: "https://example.com/1", Description: "One", Tags: []string{"a"}},
+	))
+	defer s.Close()
+
+	c := New(s.Client(), fullStore{NewMemoryStore()}, 0)
+	for i := 0; i < 2; i++ {
+		tags, err := c.TagsGet()
+		if err != nil || len(tags) != 1 {
+			t.Errorf("Wanted 1 tag despite the store failing, Got %v (%v)", tags, err)
+		}
+	}
+}
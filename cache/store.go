@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// A Store holds encoded results. Implementations must be safe for concurrent use.
+type Store interface {
+	// Get returns the value stored under key, if any.
+	Get(key string) ([]byte, bool)
+
+	// Set stores value under key.
+	Set(key string, value []byte) error
+
+	// Clear removes every stored value.
+	Clear() error
+}
+
+// A MemoryStore keeps values in memory.
+type MemoryStore struct {
+	mu     sync.RWMutex
+	values map[string][]byte
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{values: map[string][]byte{}}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(key string) ([]byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.values[key]
+	return v, ok
+}
+
+// Set implements Store.
+func (s *MemoryStore) Set(key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+	return nil
+}
+
+// Clear implements Store.
+func (s *MemoryStore) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = map[string][]byte{}
+	return nil
+}
+
+// A DirStore keeps values as files in a directory, so they survive restarts.
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore returns a DirStore in dir, creating the directory if needed.
+func NewDirStore(dir string) (*DirStore, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	return &DirStore{dir: dir}, nil
+}
+
+func (s *DirStore) path(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get implements Store.
+func (s *DirStore) Get(key string) ([]byte, bool) {
+	b, err := ioutil.ReadFile(s.path(key))
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
+
+// Set implements Store. Values are written to a temporary file first, so readers
+// never see a partial value.
+func (s *DirStore) Set(key string, value []byte) error {
+	tmp, err := ioutil.TempFile(s.dir, "tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(value)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path(key))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// Clear implements Store.
+func (s *DirStore) Clear() error {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		err = os.Remove(f)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package pinboard
+
+import (
+	"context"
+	"time"
+)
+
+// Client is the set of API methods implemented by Pinboard. Code that depends on
+// Client instead of *Pinboard can be handed fakes in tests, or decorators that add
+// caching, logging or dry-run behavior. A decorator can embed the Client it wraps
+// and override only the methods it cares about; note that each method comes in a
+// plain and a Context variant, and both need overriding.
+type Client interface {
+	PostsUpdated() (time.Time, error)
+	PostsUpdatedContext(ctx context.Context) (time.Time, error)
+	PostsAdd(pp Post, keep bool) error
+	PostsAddContext(ctx context.Context, pp Post, keep bool) error
+	PostsUpdate(pu string, fn func(*Post) error) error
+	PostsUpdateContext(ctx context.Context, pu string, fn func(*Post) error) error
+	PostsDelete(du string) error
+	PostsDeleteContext(ctx context.Context, du string) error
+	PostsGet(pf PostsFilter) ([]Post, error)
+	PostsGetContext(ctx context.Context, pf PostsFilter) ([]Post, error)
+	PostsDates(tag string) ([]PostDate, error)
+	PostsDatesContext(ctx context.Context, tag string) ([]PostDate, error)
+	PostsRecent(rpf PostsRecentFilter) ([]Post, error)
+	PostsRecentContext(ctx context.Context, rpf PostsRecentFilter) ([]Post, error)
+	PostsAll(apf PostsAllFilter) ([]Post, error)
+	PostsAllContext(ctx context.Context, apf PostsAllFilter) ([]Post, error)
+	PostsAllEach(apf PostsAllFilter, fn func(Post) error) error
+	PostsAllEachContext(ctx context.Context, apf PostsAllFilter, fn func(Post) error) error
+
+	TagsGet() ([]Tag, error)
+	TagsGetContext(ctx context.Context) ([]Tag, error)
+	TagsDelete(tag string) error
+	TagsDeleteContext(ctx context.Context, tag string) error
+	TagsRename(old, new string) error
+	TagsRenameContext(ctx context.Context, old, new string) error
+	TagsSuggestions(postUrl string) (TagSuggestions, error)
+	TagsSuggestionsContext(ctx context.Context, postUrl string) (TagSuggestions, error)
+
+	NotesList() ([]Note, error)
+	NotesListContext(ctx context.Context) ([]Note, error)
+	NotesGet(noteID string) (Note, error)
+	NotesGetContext(ctx context.Context, noteID string) (Note, error)
+
+	UserSecret() (string, error)
+	UserSecretContext(ctx context.Context) (string, error)
+	UserApiToken() (string, error)
+	UserApiTokenContext(ctx context.Context) (string, error)
+}
+
+var _ Client = (*Pinboard)(nil)
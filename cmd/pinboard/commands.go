@@ -0,0 +1,333 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	pinboard "github.com/zoni/go-pinboard"
+)
+
+var errUsage = errors.New("usage: pinboard [-json|-xml] <command> [flags] [args]; see go doc github.com/zoni/go-pinboard/cmd/pinboard")
+
+// A command defines its flags on fs and returns a function running it with the
+// remaining arguments once the flags are parsed.
+type command func(fs *flag.FlagSet) func(args []string) error
+
+// env is shared by all commands.
+type env struct {
+	ctx context.Context
+	c   pinboard.Client
+	out *output
+}
+
+func run(args []string, w io.Writer, connect func() (pinboard.Client, error)) error {
+	e := &env{ctx: context.Background(), out: &output{w: w}}
+
+	global := flag.NewFlagSet("pinboard", flag.ContinueOnError)
+	global.Usage = func() {
+		fmt.Fprintln(global.Output(), errUsage)
+		global.PrintDefaults()
+	}
+	e.out.register(global)
+	if err := global.Parse(args); err != nil {
+		return err
+	}
+	args = global.Args()
+	if len(args) < 1 {
+		return errUsage
+	}
+
+	name := args[0]
+	args = args[1:]
+	if (name == "tags" || name == "notes") && len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name += " " + args[0]
+		args = args[1:]
+	}
+
+	cmd, ok := e.commands()[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q\n%v", name, errUsage)
+	}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	e.out.register(fs)
+	runCmd := cmd(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Credentials are only needed once a command is about to run, so usage
+	// errors are reported as such on machines without any
+	c, err := connect()
+	if err != nil {
+		return err
+	}
+	e.c = c
+	return runCmd(fs.Args())
+}
+
+func (e *env) commands() map[string]command {
+	return map[string]command{
+		"add":         e.add,
+		"get":         e.get,
+		"recent":      e.recent,
+		"all":         e.all,
+		"rm":          e.rm,
+		"dates":       e.dates,
+		"tags":        e.tags,
+		"tags rename": e.tagsRename,
+		"tags rm":     e.tagsRm,
+		"suggest":     e.suggest,
+		"notes ls":    e.notesLs,
+		"notes show":  e.notesShow,
+		"secret":      e.secret,
+	}
+}
+
+// nargs checks that a command was given exactly n arguments.
+func nargs(args []string, n int, usage string) error {
+	if len(args) != n {
+		return fmt.Errorf("usage: pinboard %s", usage)
+	}
+	return nil
+}
+
+// splitTags splits a comma or space separated list of tags.
+func splitTags(s string) []string {
+	return strings.Fields(strings.Replace(s, ",", " ", -1))
+}
+
+// parseTime accepts RFC 3339 timestamps and plain dates.
+func parseTime(s string) (time.Time, error) {
+	if len(s) < 1 {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func (e *env) add(fs *flag.FlagSet) func([]string) error {
+	tags := fs.String("tags", "", "comma separated tags")
+	extended := fs.String("extended", "", "extended description")
+	private := fs.Bool("private", false, "make the post private")
+	toread := fs.Bool("toread", false, "mark the post as unread")
+	keep := fs.Bool("keep", false, "fail instead of replacing an existing post")
+	return func(args []string) error {
+		if err := nargs(args, 2, "add [flags] URL DESCRIPTION"); err != nil {
+			return err
+		}
+		pp := pinboard.Post{
+			Url:         args[0],
+			Description: args[1],
+			Extended:    *extended,
+			Tags:        splitTags(*tags),
+		}
+		if *private {
+			pp.Shared = pinboard.No
+		}
+		if *toread {
+			pp.ToRead = pinboard.Yes
+		}
+		return e.c.PostsAddContext(e.ctx, pp, *keep)
+	}
+}
+
+func (e *env) get(fs *flag.FlagSet) func([]string) error {
+	tags := fs.String("tags", "", "comma separated tags (up to 3)")
+	date := fs.String("date", "", "date to list posts from (YYYY-MM-DD)")
+	u := fs.String("url", "", "URL of a single post")
+	meta := fs.Bool("meta", false, "include change detection signatures")
+	return func(args []string) error {
+		if err := nargs(args, 0, "get [flags]"); err != nil {
+			return err
+		}
+		pf := pinboard.PostsFilter{Tags: splitTags(*tags), Url: *u, Meta: *meta}
+		var err error
+		if pf.Date, err = parseTime(*date); err != nil {
+			return err
+		}
+		posts, err := e.c.PostsGetContext(e.ctx, pf)
+		if err != nil {
+			return err
+		}
+		return e.out.posts(posts)
+	}
+}
+
+func (e *env) recent(fs *flag.FlagSet) func([]string) error {
+	tags := fs.String("tags", "", "comma separated tags (up to 3)")
+	count := fs.Int("count", 0, "number of posts (up to 100, default 15)")
+	return func(args []string) error {
+		if err := nargs(args, 0, "recent [flags]"); err != nil {
+			return err
+		}
+		posts, err := e.c.PostsRecentContext(e.ctx, pinboard.PostsRecentFilter{Tags: splitTags(*tags), Count: *count})
+		if err != nil {
+			return err
+		}
+		return e.out.posts(posts)
+	}
+}
+
+func (e *env) all(fs *flag.FlagSet) func([]string) error {
+	tags := fs.String("tags", "", "comma separated tags (up to 3)")
+	start := fs.Int("start", 0, "offset of the first post")
+	results := fs.Int("results", 0, "number of posts")
+	from := fs.String("from", "", "only posts created after this time")
+	to := fs.String("to", "", "only posts created before this time")
+	meta := fs.Bool("meta", false, "include change detection signatures")
+	return func(args []string) error {
+		if err := nargs(args, 0, "all [flags]"); err != nil {
+			return err
+		}
+		apf := pinboard.PostsAllFilter{Tags: splitTags(*tags), Start: *start, Results: *results, Meta: *meta}
+		var err error
+		if apf.From, err = parseTime(*from); err != nil {
+			return err
+		}
+		if apf.To, err = parseTime(*to); err != nil {
+			return err
+		}
+		posts, err := e.c.PostsAllContext(e.ctx, apf)
+		if err != nil {
+			return err
+		}
+		return e.out.posts(posts)
+	}
+}
+
+func (e *env) rm(fs *flag.FlagSet) func([]string) error {
+	return func(args []string) error {
+		if err := nargs(args, 1, "rm URL"); err != nil {
+			return err
+		}
+		return e.c.PostsDeleteContext(e.ctx, args[0])
+	}
+}
+
+func (e *env) dates(fs *flag.FlagSet) func([]string) error {
+	tag := fs.String("tag", "", "only count posts with this tag")
+	return func(args []string) error {
+		if err := nargs(args, 0, "dates [-tag TAG]"); err != nil {
+			return err
+		}
+		dates, err := e.c.PostsDatesContext(e.ctx, *tag)
+		if err != nil {
+			return err
+		}
+		return e.out.print("dates", dates, func(tw *tabwriter.Writer) {
+			fmt.Fprintln(tw, "DATE\tCOUNT")
+			for _, d := range dates {
+				fmt.Fprintf(tw, "%s\t%d\n", d.Date.Format("2006-01-02"), d.Count)
+			}
+		})
+	}
+}
+
+func (e *env) tags(fs *flag.FlagSet) func([]string) error {
+	return func(args []string) error {
+		if err := nargs(args, 0, "tags"); err != nil {
+			return err
+		}
+		tags, err := e.c.TagsGetContext(e.ctx)
+		if err != nil {
+			return err
+		}
+		return e.out.print("tags", tags, func(tw *tabwriter.Writer) {
+			fmt.Fprintln(tw, "TAG\tCOUNT")
+			for _, t := range tags {
+				fmt.Fprintf(tw, "%s\t%d\n", t.Tag, t.Count)
+			}
+		})
+	}
+}
+
+func (e *env) tagsRename(fs *flag.FlagSet) func([]string) error {
+	return func(args []string) error {
+		if err := nargs(args, 2, "tags rename OLD NEW"); err != nil {
+			return err
+		}
+		return e.c.TagsRenameContext(e.ctx, args[0], args[1])
+	}
+}
+
+func (e *env) tagsRm(fs *flag.FlagSet) func([]string) error {
+	return func(args []string) error {
+		if err := nargs(args, 1, "tags rm TAG"); err != nil {
+			return err
+		}
+		return e.c.TagsDeleteContext(e.ctx, args[0])
+	}
+}
+
+func (e *env) suggest(fs *flag.FlagSet) func([]string) error {
+	return func(args []string) error {
+		if err := nargs(args, 1, "suggest URL"); err != nil {
+			return err
+		}
+		ts, err := e.c.TagsSuggestionsContext(e.ctx, args[0])
+		if err != nil {
+			return err
+		}
+		return e.out.print("", ts, func(tw *tabwriter.Writer) {
+			fmt.Fprintf(tw, "POPULAR\t%s\n", strings.Join(ts.Popular, " "))
+			fmt.Fprintf(tw, "RECOMMENDED\t%s\n", strings.Join(ts.Recommended, " "))
+		})
+	}
+}
+
+func (e *env) notesLs(fs *flag.FlagSet) func([]string) error {
+	return func(args []string) error {
+		if err := nargs(args, 0, "notes ls"); err != nil {
+			return err
+		}
+		notes, err := e.c.NotesListContext(e.ctx)
+		if err != nil {
+			return err
+		}
+		return e.out.print("notes", notes, func(tw *tabwriter.Writer) {
+			fmt.Fprintln(tw, "ID\tUPDATED\tTITLE")
+			for _, n := range notes {
+				fmt.Fprintf(tw, "%s\t%s\t%s\n", n.ID, n.Updated.Format("2006-01-02 15:04"), n.Title)
+			}
+		})
+	}
+}
+
+func (e *env) notesShow(fs *flag.FlagSet) func([]string) error {
+	return func(args []string) error {
+		if err := nargs(args, 1, "notes show ID"); err != nil {
+			return err
+		}
+		n, err := e.c.NotesGetContext(e.ctx, args[0])
+		if err != nil {
+			return err
+		}
+		return e.out.print("", n, func(tw *tabwriter.Writer) {
+			fmt.Fprintf(tw, "%s\n\n%s\n", n.Title, n.Text)
+		})
+	}
+}
+
+func (e *env) secret(fs *flag.FlagSet) func([]string) error {
+	return func(args []string) error {
+		if err := nargs(args, 0, "secret"); err != nil {
+			return err
+		}
+		s, err := e.c.UserSecretContext(e.ctx)
+		if err != nil {
+			return err
+		}
+		return e.out.print("result", s, func(tw *tabwriter.Writer) {
+			fmt.Fprintln(tw, s)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// Command pinboard is a command-line client for the Pinboard API.
+//
+// Usage:
+//
+//	pinboard [-json|-xml] <command> [flags] [args]
+//
+// The commands are:
+//
+//	add [-tags a,b] [-extended text] [-private] [-toread] [-keep] URL DESCRIPTION
+//	get [-tags a,b] [-date YYYY-MM-DD] [-url URL] [-meta]
+//	recent [-tags a,b] [-count N]
+//	all [-tags a,b] [-start N] [-results N] [-from TIME] [-to TIME] [-meta]
+//	rm URL
+//	dates [-tag TAG]
+//	tags
+//	tags rename OLD NEW
+//	tags rm TAG
+//	suggest URL
+//	notes ls
+//	notes show ID
+//	secret
+//
+// Results are printed as a table, or as JSON or XML with the -json and -xml
+// flags, which may also be given after the command.
+//
+// Credentials are found with pinboard.LoadCredentials: an API token in user:TOKEN
+// form, as shown on the Pinboard settings page, in the PINBOARD_TOKEN environment
+// variable or the file ~/.config/pinboard, or a password in the api.pinboard.in
+// entry of ~/.netrc.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	pinboard "github.com/zoni/go-pinboard"
+)
+
+func main() {
+	err := run(os.Args[1:], os.Stdout, newClient)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "pinboard:", err)
+		os.Exit(1)
+	}
+}
+
+func newClient() (pinboard.Client, error) {
+	creds, err := pinboard.LoadCredentials()
+	if err != nil {
+		return nil, err
+	}
+	return pinboard.New(
+		creds.Option(),
+		pinboard.WithRetry(pinboard.DefaultRetryPolicy),
+		pinboard.WithUserAgent("go-pinboard-cli"),
+	), nil
+}
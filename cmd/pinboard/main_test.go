@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+
+	pinboard "github.com/zoni/go-pinboard"
+	"github.com/zoni/go-pinboard/pinboardtest"
+)
+
+func TestRun(t *testing.T) {
+	s := pinboardtest.NewServer()
+	defer s.Close()
+	p := s.Client()
+	connect := func() (pinboard.Client, error) { return p, nil }
+
+	steps := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"add", "-tags", "go,cli", "https://golang.org/", "The Go Programming Language"}, nil},
+		{[]string{"recent"}, []string{"https://golang.org/", "The Go Programming Language", "go cli"}},
+		{[]string{"tags", "rename", "cli", "terminal"}, nil},
+		{[]string{"-json", "tags"}, []string{`"Tag": "terminal"`}},
+		{[]string{"get", "-xml", "-url", "https://golang.org/"}, []string{`<posts>`, `href="https://golang.org/"`}},
+		{[]string{"secret", "-xml"}, []string{`<result>`}},
+		{[]string{"rm", "https://golang.org/"}, nil},
+		{[]string{"all"}, []string{"DATE"}},
+	}
+	for _, step := range steps {
+		var out bytes.Buffer
+		err := run(step.args, &out, connect)
+		if err != nil {
+			t.Fatalf("pinboard %s: %v", strings.Join(step.args, " "), err)
+		}
+		for _, w := range step.want {
+			if !strings.Contains(out.String(), w) {
+				t.Errorf("pinboard %s: wanted %q in output:\n%s", strings.Join(step.args, " "), w, out.String())
+			}
+		}
+	}
+
+	if err := run([]string{"frobnicate"}, &bytes.Buffer{}, connect); err == nil {
+		t.Error("Wanted an error for an unknown command")
+	}
+}
+
+func TestRunUsageWithoutCredentials(t *testing.T) {
+	connect := func() (pinboard.Client, error) {
+		return nil, pinboard.ErrNoCredentials
+	}
+
+	if err := run(nil, &bytes.Buffer{}, connect); err != errUsage {
+		t.Errorf("Wanted usage error without a command, Got %v", err)
+	}
+	if err := run([]string{"frobnicate"}, &bytes.Buffer{}, connect); err == nil || !strings.Contains(err.Error(), "unknown command") {
+		t.Errorf("Wanted unknown command error, Got %v", err)
+	}
+	if err := run([]string{"tags", "rm", "-h"}, &bytes.Buffer{}, connect); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Wanted flag.ErrHelp for -h, Got %v", err)
+	}
+	if err := run([]string{"secret"}, &bytes.Buffer{}, connect); !errors.Is(err, pinboard.ErrNoCredentials) {
+		t.Errorf("Wanted ErrNoCredentials once a command runs, Got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	pinboard "github.com/zoni/go-pinboard"
+)
+
+// output prints results in the format selected by the -json and -xml flags.
+type output struct {
+	w    io.Writer
+	json bool
+	xml  bool
+}
+
+func (o *output) register(fs *flag.FlagSet) {
+	fs.BoolVar(&o.json, "json", o.json, "print results as JSON")
+	fs.BoolVar(&o.xml, "xml", o.xml, "print results as XML")
+}
+
+// print writes v as JSON or XML, or calls table to print it as a table. For XML,
+// v is wrapped in an element named root unless root is empty. Strings are
+// written as the root element's text.
+func (o *output) print(root string, v interface{}, table func(tw *tabwriter.Writer)) error {
+	switch {
+	case o.json:
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(o.w, "%s\n", b)
+		return err
+	case o.xml:
+		if s, ok := v.(string); ok {
+			v = struct {
+				XMLName xml.Name
+				S       string `xml:",chardata"`
+			}{xml.Name{Local: root}, s}
+		} else if len(root) > 0 {
+			v = struct {
+				XMLName xml.Name
+				V       interface{}
+			}{xml.Name{Local: root}, v}
+		}
+		b, err := xml.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(o.w, "%s%s\n", xml.Header, b)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(o.w, 0, 8, 2, ' ', 0)
+	table(tw)
+	return tw.Flush()
+}
+
+func (o *output) posts(posts []pinboard.Post) error {
+	return o.print("posts", posts, func(tw *tabwriter.Writer) {
+		fmt.Fprintln(tw, "DATE\tURL\tDESCRIPTION\tTAGS")
+		for _, pp := range posts {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", pp.Date.Format("2006-01-02"), pp.Url, pp.Description, strings.Join(pp.Tags, " "))
+		}
+	})
+}
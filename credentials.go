@@ -0,0 +1,206 @@
+package pinboard
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Credentials authenticate a client with the API. Source describes where they were
+// found, for messages.
+type Credentials struct {
+	User     string
+	Token    string
+	Password string
+	Source   string
+}
+
+// Option returns an Option authenticating with c, using the token if there is one
+// and the password otherwise.
+func (c Credentials) Option() Option {
+	if len(c.Token) > 0 {
+		return WithToken(c.User, c.Token)
+	}
+	return WithPassword(c.User, c.Password)
+}
+
+// CredentialSources are the places LoadCredentials looks for credentials, in order
+// of precedence. Empty fields use the defaults.
+type CredentialSources struct {
+	// EnvVar names an environment variable holding an API token in user:TOKEN
+	// form. Defaults to PINBOARD_TOKEN.
+	EnvVar string
+
+	// ConfigFile is the path of a file holding an API token in user:TOKEN
+	// form. Blank lines and lines starting with # are ignored. Defaults to
+	// pinboard in the user's configuration directory (~/.config/pinboard on
+	// Linux).
+	ConfigFile string
+
+	// NetrcFile is the path of a netrc file whose api.pinboard.in entry holds
+	// a user name and password. Defaults to $NETRC, or ~/.netrc.
+	NetrcFile string
+}
+
+// netrcMachine is the host looked up in netrc files.
+const netrcMachine = "api.pinboard.in"
+
+// LoadCredentials returns the credentials found in the default sources. See
+// CredentialSources.
+func LoadCredentials() (Credentials, error) {
+	return CredentialSources{}.Load()
+}
+
+// Load returns the credentials from the first source that has them. A source
+// that exists but can't be parsed is an error rather than being skipped. If no
+// source has credentials the error matches ErrNoCredentials and lists the sources
+// checked.
+func (cs CredentialSources) Load() (Credentials, error) {
+	cs = cs.withDefaults()
+	var checked []string
+
+	checked = append(checked, "$"+cs.EnvVar)
+	if v := os.Getenv(cs.EnvVar); len(v) > 0 {
+		return parseToken(v, "$"+cs.EnvVar)
+	}
+
+	if len(cs.ConfigFile) > 0 {
+		checked = append(checked, cs.ConfigFile)
+		c, ok, err := readConfigFile(cs.ConfigFile)
+		if ok || err != nil {
+			return c, err
+		}
+	}
+
+	if len(cs.NetrcFile) > 0 {
+		checked = append(checked, cs.NetrcFile)
+		c, ok, err := readNetrc(cs.NetrcFile)
+		if ok || err != nil {
+			return c, err
+		}
+	}
+
+	return Credentials{}, fmt.Errorf("%w (checked %s)", ErrNoCredentials, strings.Join(checked, ", "))
+}
+
+func (cs CredentialSources) withDefaults() CredentialSources {
+	if len(cs.EnvVar) < 1 {
+		cs.EnvVar = "PINBOARD_TOKEN"
+	}
+	if len(cs.ConfigFile) < 1 {
+		if dir, err := os.UserConfigDir(); err == nil {
+			cs.ConfigFile = filepath.Join(dir, "pinboard")
+		}
+	}
+	if len(cs.NetrcFile) < 1 {
+		cs.NetrcFile = os.Getenv("NETRC")
+	}
+	if len(cs.NetrcFile) < 1 {
+		if home, err := os.UserHomeDir(); err == nil {
+			cs.NetrcFile = filepath.Join(home, ".netrc")
+		}
+	}
+	return cs
+}
+
+func parseToken(s, source string) (Credentials, error) {
+	s = strings.TrimSpace(s)
+	i := strings.Index(s, ":")
+	if i < 1 || i == len(s)-1 {
+		return Credentials{}, fmt.Errorf("API token from %s must be in user:TOKEN form", source)
+	}
+	return Credentials{User: s[:i], Token: s[i+1:], Source: source}, nil
+}
+
+// readConfigFile reads a token from the config file at path. It reports false if
+// the file doesn't exist.
+func readConfigFile(path string) (Credentials, bool, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Credentials{}, false, nil
+	}
+	if err != nil {
+		return Credentials{}, false, fmt.Errorf("Failed to read credentials: %w", err)
+	}
+
+	s := bufio.NewScanner(strings.NewReader(string(b)))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if len(line) < 1 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		c, err := parseToken(line, path)
+		return c, true, err
+	}
+	return Credentials{}, false, fmt.Errorf("%s contains no API token", path)
+}
+
+// readNetrc reads the api.pinboard.in entry of the netrc file at path. It reports
+// false if the file doesn't exist or has no such entry.
+func readNetrc(path string) (Credentials, bool, error) {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Credentials{}, false, nil
+	}
+	if err != nil {
+		return Credentials{}, false, fmt.Errorf("Failed to read credentials: %w", err)
+	}
+
+	c := Credentials{Source: path}
+	c.User, c.Password = parseNetrc(string(b), netrcMachine)
+	if len(c.User) < 1 && len(c.Password) < 1 {
+		return Credentials{}, false, nil
+	}
+	if len(c.User) < 1 || len(c.Password) < 1 {
+		return Credentials{}, false, fmt.Errorf("%s entry for %s needs both login and password", path, netrcMachine)
+	}
+	return c, true, nil
+}
+
+// parseNetrc returns the login and password of the entry for machine in a netrc
+// file's contents.
+func parseNetrc(data, machine string) (login, password string) {
+	matched, inMacro := false, false
+	for _, line := range strings.Split(data, "\n") {
+		// Macro definitions run until the next blank line
+		if inMacro {
+			inMacro = len(strings.TrimSpace(line)) > 0
+			continue
+		}
+
+		f := strings.Fields(line)
+		next := func(i *int) string {
+			if *i+1 < len(f) {
+				*i++
+				return f[*i]
+			}
+			return ""
+		}
+		for i := 0; i < len(f); i++ {
+			switch f[i] {
+			case "machine", "default":
+				if matched {
+					return login, password
+				}
+				matched = f[i] == "machine" && next(&i) == machine
+			case "login":
+				if v := next(&i); matched {
+					login = v
+				}
+			case "password":
+				if v := next(&i); matched {
+					password = v
+				}
+			case "account":
+				next(&i)
+			case "macdef":
+				inMacro = true
+				i = len(f)
+			}
+		}
+	}
+	return login, password
+}
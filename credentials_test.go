@@ -0,0 +1,58 @@
+package pinboard
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cs := CredentialSources{
+		EnvVar:     "GO_PINBOARD_TEST_TOKEN",
+		ConfigFile: filepath.Join(dir, "pinboard"),
+		NetrcFile:  filepath.Join(dir, "netrc"),
+	}
+	os.Unsetenv(cs.EnvVar)
+
+	_, err = cs.Load()
+	if !errors.Is(err, ErrNoCredentials) || !strings.Contains(err.Error(), cs.NetrcFile) {
+		t.Errorf("Wanted ErrNoCredentials listing the netrc file, Got %v", err)
+	}
+
+	netrc := "machine example.com login other password nope\n" +
+		"macdef init\nmachine api.pinboard.in login macro password nope\n\n" +
+		"machine api.pinboard.in\n  login drags\n  password foobar\ndefault login anon password anon\n"
+	ioutil.WriteFile(cs.NetrcFile, []byte(netrc), 0600)
+	got, err := cs.Load()
+	if err != nil || got.User != "drags" || got.Password != "foobar" || got.Source != cs.NetrcFile {
+		t.Errorf("Wanted drags:foobar from netrc, Got %+v (%v)", got, err)
+	}
+
+	ioutil.WriteFile(cs.ConfigFile, []byte("# Pinboard API token\ndrags:AC1638B3E618FD194CA0\n"), 0600)
+	got, err = cs.Load()
+	if err != nil || got.User != "drags" || got.Token != "AC1638B3E618FD194CA0" || got.Source != cs.ConfigFile {
+		t.Errorf("Wanted a token from the config file, Got %+v (%v)", got, err)
+	}
+
+	os.Setenv(cs.EnvVar, "env:0123456789ABCDEF0123")
+	defer os.Unsetenv(cs.EnvVar)
+	got, err = cs.Load()
+	if err != nil || got.User != "env" || got.Token != "0123456789ABCDEF0123" {
+		t.Errorf("Wanted a token from the environment, Got %+v (%v)", got, err)
+	}
+
+	os.Setenv(cs.EnvVar, "AC1638B3E618FD194CA0")
+	_, err = cs.Load()
+	if err == nil {
+		t.Error("Wanted an error for a token without a user")
+	}
+}
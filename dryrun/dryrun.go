@@ -0,0 +1,275 @@
+// Package dryrun provides a pinboard.Client decorator for reviewing what a script
+// would change before running it against a real account.
+//
+// Read methods pass through to the wrapped Client, but PostsAdd, PostsDelete,
+// TagsDelete and TagsRename are validated and recorded in a Plan instead of being
+// sent; PostsUpdate is recorded as the PostsAdd it would make. Reads don't see
+// the planned changes. The Plan prints as a human-readable diff, can be saved as
+// JSON, and can be applied to a real Client later.
+package dryrun
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	pinboard "github.com/zoni/go-pinboard"
+)
+
+// Methods recorded in a Mutation.
+const (
+	PostsAdd    = "posts/add"
+	PostsDelete = "posts/delete"
+	TagsDelete  = "tags/delete"
+	TagsRename  = "tags/rename"
+)
+
+// A Mutation is a single write method recorded in a Plan.
+type Mutation struct {
+	// Method is the API method, one of PostsAdd, PostsDelete, TagsDelete and
+	// TagsRename.
+	Method string `json:"method"`
+
+	// Post is the post to add for PostsAdd, and the post to delete for
+	// PostsDelete. Keep is the keep argument of PostsAdd.
+	Post pinboard.Post `json:"post"`
+	Keep bool          `json:"keep,omitempty"`
+
+	// Previous is the post PostsAdd would replace, if any.
+	Previous *pinboard.Post `json:"previous,omitempty"`
+
+	// Tag is the tag to delete for TagsDelete, and the old tag for TagsRename.
+	// NewTag is the new tag for TagsRename.
+	Tag    string `json:"tag,omitempty"`
+	NewTag string `json:"new_tag,omitempty"`
+}
+
+// String formats the mutation as a diff: added posts are marked with "+",
+// deleted posts and tags with "-", and changed posts and renamed tags with "~",
+// followed by their changed fields.
+func (m Mutation) String() string {
+	switch m.Method {
+	case PostsAdd:
+		if m.Previous == nil {
+			return fmt.Sprintf("+ %s %q [%s]", m.Post.Url, m.Post.Description, strings.Join(m.Post.Tags, " "))
+		}
+		next := m.Post
+		next.Hash = m.Previous.Hash
+		var b strings.Builder
+		fmt.Fprintf(&b, "~ %s", m.Post.Url)
+		for _, e := range pinboard.DiffPosts([]pinboard.Post{*m.Previous}, []pinboard.Post{next}) {
+			for _, c := range e.Changes {
+				fmt.Fprintf(&b, "\n    %s: %q -> %q", c.Field, c.Old, c.New)
+			}
+		}
+		return b.String()
+	case PostsDelete:
+		return fmt.Sprintf("- %s %q [%s]", m.Post.Url, m.Post.Description, strings.Join(m.Post.Tags, " "))
+	case TagsDelete:
+		return fmt.Sprintf("- tag %s", m.Tag)
+	case TagsRename:
+		return fmt.Sprintf("~ tag %s -> %s", m.Tag, m.NewTag)
+	}
+	return fmt.Sprintf("? %s", m.Method)
+}
+
+// apply sends the mutation to c.
+func (m Mutation) apply(ctx context.Context, c pinboard.Client) error {
+	switch m.Method {
+	case PostsAdd:
+		return c.PostsAddContext(ctx, m.Post, m.Keep)
+	case PostsDelete:
+		return c.PostsDeleteContext(ctx, m.Post.Url)
+	case TagsDelete:
+		return c.TagsDeleteContext(ctx, m.Tag)
+	case TagsRename:
+		return c.TagsRenameContext(ctx, m.Tag, m.NewTag)
+	}
+	return fmt.Errorf("Unknown method %q", m.Method)
+}
+
+// A Plan is the list of mutations recorded by a Client, in the order they were
+// made. It can be marshalled to JSON to be reviewed and applied later.
+type Plan struct {
+	Mutations []Mutation `json:"mutations"`
+}
+
+// String formats the plan as a diff, one mutation per line.
+func (pl Plan) String() string {
+	lines := make([]string, len(pl.Mutations))
+	for i, m := range pl.Mutations {
+		lines[i] = m.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Apply sends the mutations of the plan to c in order. It stops at the first
+// error, returning the number of mutations that were applied before it.
+func (pl Plan) Apply(ctx context.Context, c pinboard.Client) (int, error) {
+	for n, m := range pl.Mutations {
+		err := m.apply(ctx, c)
+		if err != nil {
+			return n, fmt.Errorf("Failed to apply mutation %d (%s): %w", n+1, m.Method, err)
+		}
+	}
+	return len(pl.Mutations), nil
+}
+
+// A Client records the write methods called on it in a Plan instead of passing
+// them on to the Client it wraps. All other methods pass through unchanged. It is
+// safe for concurrent use.
+//
+// Writes are validated like the API would: invalid arguments return errors
+// matching pinboard.ErrValidation, and the post a PostsAdd or PostsDelete targets
+// is looked up with PostsGet, so adding an existing post with keep set returns an
+// error matching pinboard.ErrItemExists and deleting a missing post one matching
+// pinboard.ErrItemNotFound. Rejected writes are not recorded.
+type Client struct {
+	pinboard.Client
+
+	mu   sync.Mutex
+	plan Plan
+}
+
+var _ pinboard.Client = (*Client)(nil)
+
+// New returns a Client wrapping c with an empty plan.
+func New(c pinboard.Client) *Client {
+	return &Client{Client: c}
+}
+
+// Plan returns the mutations recorded so far.
+func (c *Client) Plan() Plan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Plan{Mutations: append([]Mutation(nil), c.plan.Mutations...)}
+}
+
+// Reset empties the plan.
+func (c *Client) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.plan = Plan{}
+}
+
+func (c *Client) record(m Mutation) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.plan.Mutations = append(c.plan.Mutations, m)
+}
+
+// lookup returns the post saved for u, or nil if there is none.
+func (c *Client) lookup(ctx context.Context, u string) (*pinboard.Post, error) {
+	if len(u) < 1 {
+		return nil, fmt.Errorf("A URL is required: %w", pinboard.ErrValidation)
+	}
+	posts, err := c.Client.PostsGetContext(ctx, pinboard.PostsFilter{Url: u})
+	if err != nil || len(posts) < 1 {
+		return nil, err
+	}
+	return &posts[0], nil
+}
+
+// PostsAdd implements pinboard.Client.
+func (c *Client) PostsAdd(pp pinboard.Post, keep bool) error {
+	return c.PostsAddContext(context.Background(), pp, keep)
+}
+
+// PostsAddContext implements pinboard.Client.
+func (c *Client) PostsAddContext(ctx context.Context, pp pinboard.Post, keep bool) error {
+	err := pinboard.ValidatePost(pp)
+	if err != nil {
+		return err
+	}
+	prev, err := c.lookup(ctx, pp.Url)
+	if err != nil {
+		return fmt.Errorf("Error adding post: %w", err)
+	}
+	if prev != nil && keep {
+		return fmt.Errorf("Error adding post: %w", &pinboard.ResultError{Code: "item already exists", Endpoint: PostsAdd})
+	}
+	c.record(Mutation{Method: PostsAdd, Post: pp, Keep: keep, Previous: prev})
+	return nil
+}
+
+// PostsUpdate implements pinboard.Client.
+func (c *Client) PostsUpdate(pu string, fn func(*pinboard.Post) error) error {
+	return c.PostsUpdateContext(context.Background(), pu, fn)
+}
+
+// PostsUpdateContext implements pinboard.Client. The update is recorded as the
+// PostsAdd it would make; there is nothing to check Meta against.
+func (c *Client) PostsUpdateContext(ctx context.Context, pu string, fn func(*pinboard.Post) error) error {
+	prev, err := c.lookup(ctx, pu)
+	if err != nil {
+		return fmt.Errorf("Error updating post: %w", err)
+	}
+	if prev == nil {
+		return fmt.Errorf("No post saved for %s: %w", pu, pinboard.ErrItemNotFound)
+	}
+
+	pp := *prev
+	pp.Tags = append([]string(nil), prev.Tags...)
+	err = fn(&pp)
+	if err != nil {
+		return err
+	}
+	if pp.Url != prev.Url {
+		return fmt.Errorf("PostsUpdate cannot change the URL of a post: %w", pinboard.ErrValidation)
+	}
+	err = pinboard.ValidatePost(pp)
+	if err != nil {
+		return err
+	}
+	c.record(Mutation{Method: PostsAdd, Post: pp, Previous: prev})
+	return nil
+}
+
+// PostsDelete implements pinboard.Client.
+func (c *Client) PostsDelete(du string) error {
+	return c.PostsDeleteContext(context.Background(), du)
+}
+
+// PostsDeleteContext implements pinboard.Client.
+func (c *Client) PostsDeleteContext(ctx context.Context, du string) error {
+	pp, err := c.lookup(ctx, du)
+	if err != nil {
+		return fmt.Errorf("Error from PostsDelete request %w", err)
+	}
+	if pp == nil {
+		return fmt.Errorf("Error from PostsDelete request %w", &pinboard.ResultError{Code: "item not found", Endpoint: PostsDelete})
+	}
+	c.record(Mutation{Method: PostsDelete, Post: *pp})
+	return nil
+}
+
+// TagsDelete implements pinboard.Client.
+func (c *Client) TagsDelete(tag string) error {
+	return c.TagsDeleteContext(context.Background(), tag)
+}
+
+// TagsDeleteContext implements pinboard.Client.
+func (c *Client) TagsDeleteContext(ctx context.Context, tag string) error {
+	err := pinboard.ValidateTag(tag)
+	if err != nil {
+		return err
+	}
+	c.record(Mutation{Method: TagsDelete, Tag: tag})
+	return nil
+}
+
+// TagsRename implements pinboard.Client.
+func (c *Client) TagsRename(old, new string) error {
+	return c.TagsRenameContext(context.Background(), old, new)
+}
+
+// TagsRenameContext implements pinboard.Client.
+func (c *Client) TagsRenameContext(ctx context.Context, old, new string) error {
+	err := pinboard.ValidateTagsRename(old, new)
+	if err != nil {
+		return err
+	}
+	c.record(Mutation{Method: TagsRename, Tag: old, NewTag: new})
+	return nil
+}
@@ -0,0 +1,91 @@
+package dryrun
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	pinboard "github.com/zoni/go-pinboard"
+	"github.com/zoni/go-pinboard/pinboardtest"
+)
+
+func TestPlan(t *testing.T) {
+	date := time.Date(2011, time.March, 25, 14, 49, 56, 0, time.UTC)
+	s := pinboardtest.NewServer(pinboardtest.WithPosts(
+		pinboard.Post{Url: "https://example.com/1", Description: "One", Tags: []string{"a"}, Date: date, Shared: pinboard.Yes},
+		pinboard.Post{Url: "https://example.com/2", Description: "Two", Tags: []string{"b"}, Date: date},
+	))
+	defer s.Close()
+	c := New(s.Client())
+
+	err := c.PostsAdd(pinboard.Post{Url: "https://example.com/3", Description: "Three", Tags: []string{"c"}}, false)
+	if err != nil {
+		t.Fatalf("Error from PostsAdd: %v", err)
+	}
+	err = c.PostsUpdate("https://example.com/1", func(pp *pinboard.Post) error {
+		pp.Tags = append(pp.Tags, "z")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error from PostsUpdate: %v", err)
+	}
+	err = c.PostsDelete("https://example.com/2")
+	if err != nil {
+		t.Fatalf("Error from PostsDelete: %v", err)
+	}
+	err = c.TagsRename("a", "d")
+	if err != nil {
+		t.Fatalf("Error from TagsRename: %v", err)
+	}
+
+	err = c.PostsAdd(pinboard.Post{Url: "https://example.com/1", Description: "One"}, true)
+	if !errors.Is(err, pinboard.ErrItemExists) {
+		t.Errorf("Wanted ErrItemExists from PostsAdd, Got %v", err)
+	}
+	err = c.PostsDelete("https://example.com/missing")
+	if !errors.Is(err, pinboard.ErrItemNotFound) {
+		t.Errorf("Wanted ErrItemNotFound from PostsDelete, Got %v", err)
+	}
+	err = c.TagsDelete("")
+	if !errors.Is(err, pinboard.ErrValidation) {
+		t.Errorf("Wanted ErrValidation from TagsDelete, Got %v", err)
+	}
+
+	if n := s.Calls("posts/add") + s.Calls("posts/delete") + s.Calls("tags/rename"); n != 0 {
+		t.Fatalf("Wanted no writes to reach the API, Got %d", n)
+	}
+
+	want := `+ https://example.com/3 "Three" [c]
+~ https://example.com/1
+    Tags: "a" -> "a z"
+- https://example.com/2 "Two" [b]
+~ tag a -> d`
+	if got := c.Plan().String(); got != want {
+		t.Errorf("Wanted plan\n%s\nGot\n%s", want, got)
+	}
+
+	// The plan survives a round trip through JSON and applies to the account
+	b, err := json.Marshal(c.Plan())
+	if err != nil {
+		t.Fatalf("Error marshalling plan: %v", err)
+	}
+	var plan Plan
+	err = json.Unmarshal(b, &plan)
+	if err != nil {
+		t.Fatalf("Error unmarshalling plan: %v", err)
+	}
+	n, err := plan.Apply(context.Background(), s.Client())
+	if err != nil || n != 4 {
+		t.Fatalf("Wanted 4 mutations applied, Got %d (%v)", n, err)
+	}
+
+	got := map[string]string{}
+	for _, pp := range s.Posts() {
+		got[pp.Url] = pp.Tags[0] + " " + pp.Shared.String()
+	}
+	if len(got) != 2 || got["https://example.com/1"] != "d yes" || got["https://example.com/3"] != "c yes" {
+		t.Errorf("Wanted posts 1 and 3 after applying the plan, Got %v", got)
+	}
+}
@@ -1,6 +1,8 @@
 package pinboard
 
 import "encoding/json"
+import "fmt"
+import "strconv"
 import "strings"
 import "time"
 
@@ -27,6 +29,16 @@ func (t postTags) MarshalJSON() ([]byte, error) {
 	return json.Marshal([]string(t))
 }
 
+// UnmarshalJSON accepts both the list written by MarshalJSON and the space
+// delimited string used by the API's JSON format.
+func (t *postTags) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return t.UnmarshalText([]byte(s))
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
 // utcDate is a type for parsing _some_ of the dates returned by the Pinboard API.
 type utcDate struct {
 	time.Time
@@ -48,6 +60,14 @@ func (u utcDate) MarshalJSON() ([]byte, error) {
 	return json.Marshal(s)
 }
 
+func (u *utcDate) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return u.UnmarshalText([]byte(s))
+}
+
 // notesDate is a type for parsing the datetime stamps in the notes list
 type notesDate struct {
 	time.Time
@@ -63,3 +83,81 @@ func (n *notesDate) UnmarshalText(text []byte) error {
 	*n = notesDate{d}
 	return err
 }
+
+// UnmarshalJSON accepts both the format used by the API and the RFC 3339
+// timestamps written by the embedded time.Time's MarshalJSON.
+func (n *notesDate) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if err := n.UnmarshalText([]byte(s)); err == nil {
+		return nil
+	}
+	return n.Time.UnmarshalText([]byte(s))
+}
+
+// YesNo is a flag represented as "yes" or "no" by the Pinboard API. It is used for
+// the Shared and ToRead fields of Post. The zero value is unset, which PostsAdd
+// doesn't send, so the API's default applies.
+type YesNo int8
+
+// The set values of a YesNo.
+const (
+	No YesNo = iota + 1
+	Yes
+)
+
+// NewYesNo returns Yes if b is true and No otherwise.
+func NewYesNo(b bool) YesNo {
+	if b {
+		return Yes
+	}
+	return No
+}
+
+// IsSet reports whether b is Yes or No.
+func (b YesNo) IsSet() bool {
+	return b == Yes || b == No
+}
+
+// String returns "yes", "no", or the empty string if b is unset.
+func (b YesNo) String() string {
+	switch b {
+	case Yes:
+		return "yes"
+	case No:
+		return "no"
+	}
+	return ""
+}
+
+func (b YesNo) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText accepts "yes" and "no" as well as "true", "false", "1" and "0",
+// in any case. The empty string leaves b unset.
+func (b *YesNo) UnmarshalText(text []byte) error {
+	switch strings.ToLower(string(text)) {
+	case "yes", "true", "1":
+		*b = Yes
+	case "no", "false", "0":
+		*b = No
+	case "":
+		*b = 0
+	default:
+		return fmt.Errorf("Invalid yes/no value %q", text)
+	}
+	return nil
+}
+
+// jsonInt is a type for parsing counts in the API's JSON format, which are
+// sometimes sent as strings.
+type jsonInt int
+
+func (n *jsonInt) UnmarshalJSON(data []byte) error {
+	i, err := strconv.Atoi(strings.Trim(string(data), `"`))
+	*n = jsonInt(i)
+	return err
+}
@@ -1,6 +1,7 @@
 package pinboard
 
 import (
+	"encoding/json"
 	"encoding/xml"
 	"reflect"
 	"testing"
@@ -57,3 +58,43 @@ func TestNotesDateUnmarshal(t *testing.T) {
 		t.Errorf("Wanted %v, got %v", want, got.Created)
 	}
 }
+
+func TestPostTagsUnmarshalJSON(t *testing.T) {
+	want := postTags{"foo", "bar", "baz"}
+	for _, body := range []string{`"foo bar baz"`, `["foo","bar","baz"]`} {
+		var got postTags
+		err := json.Unmarshal([]byte(body), &got)
+		if err != nil {
+			t.Errorf("Failed to unmarshal %s: %v", body, err)
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("Wanted %v, got %v", want, got)
+		}
+	}
+}
+
+func TestYesNoUnmarshal(t *testing.T) {
+	type yesNoTest struct {
+		XMLName xml.Name
+		Shared  YesNo `xml:"shared,attr"`
+		ToRead  YesNo `xml:"toread,attr"`
+	}
+	got := &yesNoTest{}
+	body := `<post shared="no" toread="yes"/>`
+	err := xml.Unmarshal([]byte(body), got)
+	if err != nil {
+		t.Errorf("Failed to %v unmarshal body", err)
+	}
+	if got.Shared != No || got.ToRead != Yes {
+		t.Errorf("Wanted shared=no toread=yes, got %v", got)
+	}
+
+	out, err := xml.Marshal(got)
+	if err != nil {
+		t.Errorf("Failed to marshal: %v", err)
+	}
+	want := `<post shared="no" toread="yes"></post>`
+	if string(out) != want {
+		t.Errorf("Wanted %s, got %s", want, out)
+	}
+}
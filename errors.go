@@ -0,0 +1,104 @@
+package pinboard
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrAuthFailed is returned when the API rejects the client's credentials.
+	ErrAuthFailed = errors.New("pinboard: authentication failed")
+
+	// ErrRateLimited is returned when the API answers 429 Too Many Requests, or
+	// when a fail-fast rate limiter refuses a call.
+	ErrRateLimited = errors.New("pinboard: rate limited")
+
+	// ErrValidation is returned when arguments are rejected before a request is
+	// made, such as a post without a URL or a filter with too many tags.
+	ErrValidation = errors.New("pinboard: validation failed")
+
+	// ErrItemExists is returned when PostsAdd is told to keep an existing post.
+	ErrItemExists = errors.New("pinboard: item already exists")
+
+	// ErrItemNotFound is returned when a write method targets a post or tag that
+	// does not exist.
+	ErrItemNotFound = errors.New("pinboard: item not found")
+
+	// ErrConflict is returned by PostsUpdate when the post was modified by someone
+	// else between reading and writing it.
+	ErrConflict = errors.New("pinboard: post modified concurrently")
+
+	// ErrNoCredentials is returned by LoadCredentials when none of the sources
+	// it checks hold credentials.
+	ErrNoCredentials = errors.New("pinboard: no credentials found")
+
+	// ErrStopIteration can be returned by the callback of PostsAllEach to stop
+	// iterating without an error.
+	ErrStopIteration = errors.New("pinboard: stop iteration")
+)
+
+// An APIError is returned when the Pinboard API answers with an HTTP error status.
+// It matches ErrAuthFailed or ErrRateLimited with errors.Is where appropriate.
+type APIError struct {
+	StatusCode int
+	Body       string
+	Endpoint   string
+}
+
+func (e *APIError) Error() string {
+	if e.StatusCode == http.StatusUnauthorized {
+		return fmt.Sprintf("Error from Pinboard API %s: Authentication Failed", e.Endpoint)
+	}
+	return fmt.Sprintf("Error from Pinboard API %s (%d): %v", e.Endpoint, e.StatusCode, e.Body)
+}
+
+func (e *APIError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusUnauthorized:
+		return ErrAuthFailed
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	}
+	return nil
+}
+
+// A ResultError is returned when a write method is answered with a result code
+// other than "done". It matches ErrItemExists or ErrItemNotFound with errors.Is
+// where appropriate.
+type ResultError struct {
+	Code     string
+	Endpoint string
+}
+
+func (e *ResultError) Error() string {
+	return fmt.Sprintf("Pinboard API %s returned %q", e.Endpoint, e.Code)
+}
+
+func (e *ResultError) Unwrap() error {
+	switch e.Code {
+	case "item already exists":
+		return ErrItemExists
+	case "item not found":
+		return ErrItemNotFound
+	}
+	return nil
+}
+
+// validationError keeps the message of a failed argument check while matching
+// ErrValidation with errors.Is.
+type validationError struct {
+	msg string
+}
+
+func (e *validationError) Error() string {
+	return e.msg
+}
+
+func (e *validationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+func validationErrorf(format string, a ...interface{}) error {
+	return &validationError{fmt.Sprintf(format, a...)}
+}
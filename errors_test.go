@@ -0,0 +1,69 @@
+package pinboard
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAPIError(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "401 Forbidden", http.StatusUnauthorized)
+	}))
+	defer s.Close()
+
+	p := New(WithToken("drags", "AC1638B3E618FD194CA0"), WithBaseURL(s.URL))
+	_, err := p.TagsGet()
+	if !errors.Is(err, ErrAuthFailed) {
+		t.Errorf("Wanted ErrAuthFailed, Got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Wanted an *APIError, Got %T", err)
+	}
+	if apiErr.StatusCode != 401 || apiErr.Endpoint != "tags/get" {
+		t.Errorf("Wanted 401 from tags/get, Got %d from %s", apiErr.StatusCode, apiErr.Endpoint)
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	err := p2.PostsAdd(Post{Url: "gopher://example.com", Description: "Gopher"}, false)
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("Wanted ErrValidation for an invalid scheme, Got %v", err)
+	}
+
+	_, err = p3.TagsGet()
+	if !errors.Is(err, ErrValidation) {
+		t.Errorf("Wanted ErrValidation for missing credentials, Got %v", err)
+	}
+}
+
+func TestResultError(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/posts/add":
+			fmt.Fprint(w, `<result code="item already exists" />`)
+		case "/posts/delete":
+			fmt.Fprint(w, `<result code="item not found" />`)
+		case "/tags/rename":
+			fmt.Fprint(w, `<result>done</result>`)
+		}
+	}))
+	defer s.Close()
+
+	p := New(WithToken("drags", "AC1638B3E618FD194CA0"), WithBaseURL(s.URL))
+	err := p.PostsAdd(Post{Url: "https://example.com", Description: "Example"}, true)
+	if !errors.Is(err, ErrItemExists) {
+		t.Errorf("Wanted ErrItemExists from PostsAdd, Got %v", err)
+	}
+	err = p.PostsDelete("https://example.com")
+	if !errors.Is(err, ErrItemNotFound) {
+		t.Errorf("Wanted ErrItemNotFound from PostsDelete, Got %v", err)
+	}
+	err = p.TagsRename("old", "new")
+	if err != nil {
+		t.Errorf("Error from TagsRename: %v", err)
+	}
+}
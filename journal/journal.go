@@ -0,0 +1,230 @@
+// Package journal provides a pinboard.Client decorator that makes destructive
+// operations undoable.
+//
+// Before PostsDelete, TagsDelete or TagsRename is sent, the posts it affects are
+// captured (with PostsGet for a deleted post, PostsAll for the posts carrying a
+// deleted or renamed tag) and appended to a journal file as an Entry. Undo adds the
+// posts of an entry back as they were, so a mistyped tag deletion can be reverted.
+//
+// The journal is a file of JSON lines that is only ever appended to. Undoing an
+// entry appends another entry recording the undo.
+package journal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	pinboard "github.com/zoni/go-pinboard"
+)
+
+// Operations recorded in a journal Entry.
+const (
+	OpPostsDelete = "posts/delete"
+	OpTagsDelete  = "tags/delete"
+	OpTagsRename  = "tags/rename"
+	OpUndo        = "undo"
+)
+
+// An Entry is a single operation recorded in the journal.
+type Entry struct {
+	// ID identifies the entry. IDs are numbered from 1 in journal order.
+	ID   int       `json:"id"`
+	Time time.Time `json:"time"`
+
+	// Op is the operation and Args its arguments: the URL for OpPostsDelete,
+	// the tag for OpTagsDelete and the old and new tag for OpTagsRename.
+	Op   string   `json:"op"`
+	Args []string `json:"args"`
+
+	// Posts holds the affected posts as they were before the operation.
+	Posts []pinboard.Post `json:"posts,omitempty"`
+
+	// Undoes is the ID of the entry an OpUndo entry reverted.
+	Undoes int `json:"undoes,omitempty"`
+}
+
+// A Client records the posts affected by PostsDelete, TagsDelete and TagsRename
+// in a journal before passing them on to the Client it wraps. All other methods
+// pass through unchanged. It is safe for concurrent use.
+type Client struct {
+	pinboard.Client
+
+	path string
+
+	mu   sync.Mutex
+	last int
+}
+
+var _ pinboard.Client = (*Client)(nil)
+
+// Open returns a Client wrapping c that records entries in the journal file at
+// path. The file is created on the first entry if it doesn't exist.
+func Open(c pinboard.Client, path string) (*Client, error) {
+	jc := &Client{Client: c, path: path}
+	entries, err := jc.Entries()
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		jc.last = entries[len(entries)-1].ID
+	}
+	return jc, nil
+}
+
+// Entries returns every entry in the journal, oldest first.
+func (c *Client) Entries() ([]Entry, error) {
+	f, err := os.Open(c.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read journal: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 64<<20)
+	for s.Scan() {
+		var e Entry
+		err := json.Unmarshal(s.Bytes(), &e)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to parse journal %s entry %d: %w", c.path, len(entries)+1, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("Failed to read journal: %w", err)
+	}
+	return entries, nil
+}
+
+// record numbers e and appends it to the journal.
+func (c *Client) record(e Entry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e.ID = c.last + 1
+	e.Time = time.Now().UTC()
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("Failed to encode journal entry: %w", err)
+	}
+
+	f, err := os.OpenFile(c.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("Failed to open journal: %w", err)
+	}
+	_, err = f.Write(append(b, '\n'))
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to write journal: %w", err)
+	}
+
+	c.last = e.ID
+	return nil
+}
+
+// PostsDelete implements pinboard.Client.
+func (c *Client) PostsDelete(du string) error {
+	return c.PostsDeleteContext(context.Background(), du)
+}
+
+// PostsDeleteContext implements pinboard.Client. The post is captured with
+// PostsGet before it is deleted.
+func (c *Client) PostsDeleteContext(ctx context.Context, du string) error {
+	posts, err := c.Client.PostsGetContext(ctx, pinboard.PostsFilter{Url: du})
+	if err != nil {
+		return fmt.Errorf("Failed to capture post for journal: %w", err)
+	}
+	err = c.record(Entry{Op: OpPostsDelete, Args: []string{du}, Posts: posts})
+	if err != nil {
+		return err
+	}
+	return c.Client.PostsDeleteContext(ctx, du)
+}
+
+// TagsDelete implements pinboard.Client.
+func (c *Client) TagsDelete(tag string) error {
+	return c.TagsDeleteContext(context.Background(), tag)
+}
+
+// TagsDeleteContext implements pinboard.Client. The posts tagged with tag are
+// captured with PostsAll before the tag is deleted.
+func (c *Client) TagsDeleteContext(ctx context.Context, tag string) error {
+	posts, err := c.Client.PostsAllContext(ctx, pinboard.PostsAllFilter{Tags: []string{tag}})
+	if err != nil {
+		return fmt.Errorf("Failed to capture posts for journal: %w", err)
+	}
+	err = c.record(Entry{Op: OpTagsDelete, Args: []string{tag}, Posts: posts})
+	if err != nil {
+		return err
+	}
+	return c.Client.TagsDeleteContext(ctx, tag)
+}
+
+// TagsRename implements pinboard.Client.
+func (c *Client) TagsRename(old, new string) error {
+	return c.TagsRenameContext(context.Background(), old, new)
+}
+
+// TagsRenameContext implements pinboard.Client. The posts tagged with old are
+// captured with PostsAll before the tag is renamed.
+func (c *Client) TagsRenameContext(ctx context.Context, old, new string) error {
+	posts, err := c.Client.PostsAllContext(ctx, pinboard.PostsAllFilter{Tags: []string{old}})
+	if err != nil {
+		return fmt.Errorf("Failed to capture posts for journal: %w", err)
+	}
+	err = c.record(Entry{Op: OpTagsRename, Args: []string{old, new}, Posts: posts})
+	if err != nil {
+		return err
+	}
+	return c.Client.TagsRenameContext(ctx, old, new)
+}
+
+// Undo reverts the entry with the given ID by adding its posts back with
+// pinboard.Restore, which is passed opts. Posts are restored as they were when the
+// entry was recorded, replacing any later edits to them. Posts that fail to be
+// added are listed in the returned summary; the undo is only recorded in the
+// journal if none failed, so it can be retried. Undoing an entry twice, or
+// undoing an undo, is an error.
+func (c *Client) Undo(ctx context.Context, id int, opts pinboard.RestoreOptions) (pinboard.RestoreSummary, error) {
+	var sum pinboard.RestoreSummary
+
+	entries, err := c.Entries()
+	if err != nil {
+		return sum, err
+	}
+	var target *Entry
+	for i, e := range entries {
+		if e.ID == id {
+			target = &entries[i]
+		}
+		if e.Op == OpUndo && e.Undoes == id {
+			return sum, fmt.Errorf("Journal entry %d has already been undone by entry %d", id, e.ID)
+		}
+	}
+	if target == nil {
+		return sum, fmt.Errorf("No journal entry with ID %d", id)
+	}
+	if target.Op == OpUndo {
+		return sum, fmt.Errorf("Journal entry %d is an undo and can't be undone", id)
+	}
+
+	sum, err = pinboard.Restore(ctx, c.Client, target.Posts, opts)
+	if err != nil || len(sum.Failed) > 0 {
+		return sum, err
+	}
+	err = c.record(Entry{Op: OpUndo, Undoes: id})
+	return sum, err
+}
@@ -0,0 +1,93 @@
+package journal
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	pinboard "github.com/zoni/go-pinboard"
+	"github.com/zoni/go-pinboard/pinboardtest"
+)
+
+func TestUndo(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal")
+
+	date := time.Date(2011, time.March, 25, 14, 49, 56, 0, time.UTC)
+	s := pinboardtest.NewServer(pinboardtest.WithPosts(
+		pinboard.Post{Url: "https://example.com/1", Description: "One", Tags: []string{"a", "b"}, Date: date, Shared: pinboard.Yes},
+		pinboard.Post{Url: "https://example.com/2", Description: "Two", Tags: []string{"a"}, Date: date.Add(-time.Hour)},
+		pinboard.Post{Url: "https://example.com/3", Description: "Three", Tags: []string{"c"}, Date: date.Add(-2 * time.Hour)},
+	))
+	defer s.Close()
+	want := s.Posts()
+
+	c, err := Open(s.Client(), path)
+	if err != nil {
+		t.Fatalf("Error from Open: %v", err)
+	}
+	err = c.TagsDelete("a")
+	if err != nil {
+		t.Fatalf("Error from TagsDelete: %v", err)
+	}
+	err = c.TagsRename("c", "d")
+	if err != nil {
+		t.Fatalf("Error from TagsRename: %v", err)
+	}
+	err = c.PostsDelete("https://example.com/1")
+	if err != nil {
+		t.Fatalf("Error from PostsDelete: %v", err)
+	}
+	if got := s.Posts(); len(got) != 2 {
+		t.Fatalf("Wanted 2 posts after PostsDelete, Got %v", got)
+	}
+
+	// Reopening continues the journal
+	c, err = Open(s.Client(), path)
+	if err != nil {
+		t.Fatalf("Error from Open: %v", err)
+	}
+	entries, err := c.Entries()
+	if err != nil {
+		t.Fatalf("Error from Entries: %v", err)
+	}
+	var ops []string
+	for _, e := range entries {
+		ops = append(ops, e.Op)
+	}
+	if !reflect.DeepEqual(ops, []string{OpTagsDelete, OpTagsRename, OpPostsDelete}) || len(entries[0].Posts) != 2 {
+		t.Fatalf("Wanted tags/delete, tags/rename and posts/delete entries, Got %v", entries)
+	}
+
+	opts := pinboard.RestoreOptions{Interval: time.Millisecond}
+	for _, id := range []int{3, 2, 1} {
+		_, err = c.Undo(context.Background(), id, opts)
+		if err != nil {
+			t.Fatalf("Error from Undo(%d): %v", id, err)
+		}
+	}
+	got := s.Posts()
+	if len(got) != len(want) {
+		t.Fatalf("Wanted %d posts after Undo, Got %v", len(want), got)
+	}
+	for i := range want {
+		if got[i].Url != want[i].Url || !reflect.DeepEqual(got[i].Tags, want[i].Tags) ||
+			!got[i].Date.Equal(want[i].Date) || got[i].Shared != want[i].Shared {
+			t.Errorf("Wanted %v after Undo, Got %v", want[i], got[i])
+		}
+	}
+
+	_, err = c.Undo(context.Background(), 1, opts)
+	if err == nil || !strings.Contains(err.Error(), "already been undone") {
+		t.Errorf("Wanted error undoing entry twice, Got %v", err)
+	}
+}
@@ -0,0 +1,251 @@
+package pinboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"sort"
+	"strings"
+	"time"
+)
+
+// jsonResponse is implemented by every type the API returns. The JSON format is
+// shaped differently from the XML one (posts/all returns a bare array, tags/get
+// and posts/dates return objects keyed by tag and date, counts are sometimes
+// quoted), so each type converts its JSON representation into the same value
+// the XML decoder would produce.
+type jsonResponse interface {
+	decodeJSON([]byte) error
+}
+
+// jsonPost is a post as represented in the API's JSON format.
+type jsonPost struct {
+	Href        string    `json:"href"`
+	Description string    `json:"description"`
+	Extended    string    `json:"extended"`
+	Meta        string    `json:"meta"`
+	Hash        string    `json:"hash"`
+	Time        time.Time `json:"time"`
+	Shared      YesNo     `json:"shared"`
+	ToRead      YesNo     `json:"toread"`
+	Tags        string    `json:"tags"`
+}
+
+func newJSONPost(pp Post) jsonPost {
+	return jsonPost{
+		Href:        pp.Url,
+		Description: pp.Description,
+		Extended:    pp.Extended,
+		Meta:        pp.Meta,
+		Hash:        pp.Hash,
+		Time:        pp.Date,
+		Shared:      pp.Shared,
+		ToRead:      pp.ToRead,
+		Tags:        strings.Join(pp.Tags, " "),
+	}
+}
+
+func (jp jsonPost) post() Post {
+	var tags postTags
+	tags.UnmarshalText([]byte(jp.Tags))
+	return Post{
+		XMLName:     xml.Name{Local: "post"},
+		Url:         jp.Href,
+		Description: jp.Description,
+		Hash:        jp.Hash,
+		Tags:        tags,
+		Extended:    jp.Extended,
+		Date:        jp.Time,
+		Shared:      jp.Shared,
+		ToRead:      jp.ToRead,
+		Meta:        jp.Meta,
+	}
+}
+
+func (ps *posts) decodeJSON(b []byte) error {
+	var wire struct {
+		User  string     `json:"user"`
+		Date  time.Time  `json:"date"`
+		Posts []jsonPost `json:"posts"`
+	}
+
+	// posts/all returns a bare array, posts/get and posts/recent an object
+	var err error
+	if b = bytes.TrimSpace(b); len(b) > 0 && b[0] == '[' {
+		err = json.Unmarshal(b, &wire.Posts)
+	} else {
+		err = json.Unmarshal(b, &wire)
+	}
+	if err != nil {
+		return err
+	}
+
+	ps.XMLName = xml.Name{Local: "posts"}
+	ps.User = wire.User
+	ps.Date = wire.Date
+	for _, jp := range wire.Posts {
+		ps.Posts = append(ps.Posts, jp.post())
+	}
+	return nil
+}
+
+func (up *postsLastUpdate) decodeJSON(b []byte) error {
+	var wire struct {
+		UpdateTime time.Time `json:"update_time"`
+	}
+	err := json.Unmarshal(b, &wire)
+	if err != nil {
+		return err
+	}
+
+	up.XMLName = xml.Name{Local: "update"}
+	up.UpdateTime = wire.UpdateTime
+	return nil
+}
+
+// jsonCounts maps tags or dates to counts. The API encodes an empty map as an
+// empty array.
+type jsonCounts map[string]jsonInt
+
+func (c *jsonCounts) UnmarshalJSON(data []byte) error {
+	var list []json.RawMessage
+	if json.Unmarshal(data, &list) == nil && len(list) == 0 {
+		*c = jsonCounts{}
+		return nil
+	}
+	return json.Unmarshal(data, (*map[string]jsonInt)(c))
+}
+
+func (pd *postDates) decodeJSON(b []byte) error {
+	var wire struct {
+		User  string     `json:"user"`
+		Tag   string     `json:"tag"`
+		Dates jsonCounts `json:"dates"`
+	}
+	err := json.Unmarshal(b, &wire)
+	if err != nil {
+		return err
+	}
+
+	pd.XMLName = xml.Name{Local: "dates"}
+	pd.User = wire.User
+	pd.Tag = wire.Tag
+	for k, v := range wire.Dates {
+		d := PostDate{XMLName: xml.Name{Local: "date"}, Count: int(v)}
+		err = d.Date.UnmarshalText([]byte(k))
+		if err != nil {
+			return err
+		}
+		pd.PostDates = append(pd.PostDates, d)
+	}
+
+	// The XML format lists the most recent date first
+	sort.Slice(pd.PostDates, func(i, j int) bool {
+		return pd.PostDates[i].Date.After(pd.PostDates[j].Date.Time)
+	})
+	return nil
+}
+
+func (t *tags) decodeJSON(b []byte) error {
+	var wire jsonCounts
+	err := json.Unmarshal(b, &wire)
+	if err != nil {
+		return err
+	}
+
+	t.XMLName = xml.Name{Local: "tags"}
+	for k, v := range wire {
+		t.Tags = append(t.Tags, Tag{XMLName: xml.Name{Local: "tag"}, Tag: k, Count: int(v)})
+	}
+
+	// The XML format lists tags alphabetically
+	sort.Slice(t.Tags, func(i, j int) bool {
+		return t.Tags[i].Tag < t.Tags[j].Tag
+	})
+	return nil
+}
+
+func (ts *TagSuggestions) decodeJSON(b []byte) error {
+	var wire []struct {
+		Popular     []string `json:"popular"`
+		Recommended []string `json:"recommended"`
+	}
+	err := json.Unmarshal(b, &wire)
+	if err != nil {
+		return err
+	}
+
+	ts.XMLName = xml.Name{Local: "suggested"}
+	for _, s := range wire {
+		ts.Popular = append(ts.Popular, s.Popular...)
+		ts.Recommended = append(ts.Recommended, s.Recommended...)
+	}
+	return nil
+}
+
+// jsonNote is a note as represented in the API's JSON format.
+type jsonNote struct {
+	ID      string    `json:"id"`
+	Title   string    `json:"title"`
+	Hash    string    `json:"hash"`
+	Created notesDate `json:"created_at"`
+	Updated notesDate `json:"updated_at"`
+	Length  jsonInt   `json:"length"`
+	Text    string    `json:"text"`
+}
+
+func (jn jsonNote) note() Note {
+	return Note{
+		XMLName: xml.Name{Local: "note"},
+		ID:      jn.ID,
+		Title:   jn.Title,
+		Hash:    jn.Hash,
+		Created: jn.Created,
+		Updated: jn.Updated,
+		Length:  int(jn.Length),
+		Text:    jn.Text,
+	}
+}
+
+func (n *notes) decodeJSON(b []byte) error {
+	var wire struct {
+		Notes []jsonNote `json:"notes"`
+	}
+	err := json.Unmarshal(b, &wire)
+	if err != nil {
+		return err
+	}
+
+	n.XMLName = xml.Name{Local: "notes"}
+	for _, jn := range wire.Notes {
+		n.Notes = append(n.Notes, jn.note())
+	}
+	return nil
+}
+
+func (n *Note) decodeJSON(b []byte) error {
+	var wire jsonNote
+	err := json.Unmarshal(b, &wire)
+	if err != nil {
+		return err
+	}
+
+	*n = wire.note()
+	return nil
+}
+
+func (r *result) decodeJSON(b []byte) error {
+	var wire struct {
+		Code   string `json:"result_code"`
+		Result string `json:"result"`
+	}
+	err := json.Unmarshal(b, &wire)
+	if err != nil {
+		return err
+	}
+
+	r.XMLName = xml.Name{Local: "result"}
+	r.Code = wire.Code
+	r.Result = wire.Result
+	return nil
+}
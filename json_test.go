@@ -0,0 +1,148 @@
+package pinboard
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var wireResponses = map[string][2]string{
+	"/posts/get": {
+		`<posts dt="2011-03-25T14:49:56Z" user="drags">
+  <post href="https://example.com/" description="Example" extended="An example" hash="c984d06aafbecf6bc55569f964148ea3" meta="92959a96fd69146c5fe7cbde6e5720f2" shared="no" toread="yes" tag="example .private" time="2011-03-25T14:49:56Z" />
+</posts>`,
+		`{"date":"2011-03-25T14:49:56Z","user":"drags","posts":[{"href":"https:\/\/example.com\/","description":"Example","extended":"An example","meta":"92959a96fd69146c5fe7cbde6e5720f2","hash":"c984d06aafbecf6bc55569f964148ea3","time":"2011-03-25T14:49:56Z","shared":"no","toread":"yes","tags":"example .private"}]}`,
+	},
+	"/posts/dates": {
+		`<dates tag="" user="drags">
+  <date count="5" date="2011-03-25" />
+  <date count="1" date="2011-03-23" />
+</dates>`,
+		`{"user":"drags","tag":"","dates":{"2011-03-23":"1","2011-03-25":"5"}}`,
+	},
+	"/tags/get": {
+		`<tags>
+  <tag count="1" tag="activedesktop" />
+  <tag count="3" tag="business" />
+</tags>`,
+		`{"business":"3","activedesktop":1}`,
+	},
+	"/notes/list": {
+		`<notes count="1">
+  <note id="cf73b8a87f3d4e8d1f4d">
+    <hash>0c9c30f60cadabd31415</hash>
+    <title>Paul Graham on Hirin'</title>
+    <created_at>2011-10-28 13:37:23</created_at>
+    <updated_at>2011-10-28 13:37:23</updated_at>
+    <length>1234</length>
+  </note>
+</notes>`,
+		`{"count":1,"notes":[{"id":"cf73b8a87f3d4e8d1f4d","hash":"0c9c30f60cadabd31415","title":"Paul Graham on Hirin'","length":"1234","created_at":"2011-10-28 13:37:23","updated_at":"2011-10-28 13:37:23"}]}`,
+	},
+}
+
+func TestJSONMatchesXML(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res := wireResponses[r.URL.Path]
+		if r.URL.Query().Get("format") == "json" {
+			fmt.Fprint(w, res[1])
+			return
+		}
+		fmt.Fprint(w, res[0])
+	}))
+	defer s.Close()
+
+	px := New(WithToken("drags", "AC1638B3E618FD194CA0"), WithBaseURL(s.URL))
+	pj := New(WithToken("drags", "AC1638B3E618FD194CA0"), WithBaseURL(s.URL), WithJSON())
+
+	calls := map[string]func(p *Pinboard) (interface{}, error){
+		"PostsGet":   func(p *Pinboard) (interface{}, error) { return p.PostsGet(PostsFilter{}) },
+		"PostsDates": func(p *Pinboard) (interface{}, error) { return p.PostsDates("") },
+		"TagsGet":    func(p *Pinboard) (interface{}, error) { return p.TagsGet() },
+		"NotesList":  func(p *Pinboard) (interface{}, error) { return p.NotesList() },
+	}
+	for name, call := range calls {
+		want, err := call(px)
+		if err != nil {
+			t.Errorf("%s: error from XML request: %v", name, err)
+			continue
+		}
+		got, err := call(pj)
+		if err != nil {
+			t.Errorf("%s: error from JSON request: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("%s: JSON decoded differently from XML.\nWant %#v\nGot  %#v", name, want, got)
+		}
+	}
+}
+
+func TestJSONEmptyCounts(t *testing.T) {
+	// PHP's json_encode writes an empty map as an empty array
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/posts/dates" {
+			fmt.Fprint(w, `{"user":"drags","tag":"","dates":[]}`)
+			return
+		}
+		fmt.Fprint(w, `[]`)
+	}))
+	defer s.Close()
+	p := New(WithToken("drags", "AC1638B3E618FD194CA0"), WithBaseURL(s.URL), WithJSON())
+
+	tags, err := p.TagsGet()
+	if err != nil || len(tags) != 0 {
+		t.Errorf("Wanted no tags, Got %v (%v)", tags, err)
+	}
+	dates, err := p.PostsDates("")
+	if err != nil || len(dates) != 0 {
+		t.Errorf("Wanted no dates, Got %v (%v)", dates, err)
+	}
+}
+
+var testBackup = `[{"href":"https:\/\/example.com\/","description":"Example","extended":"An example","meta":"92959a96fd69146c5fe7cbde6e5720f2","hash":"c984d06aafbecf6bc55569f964148ea3","time":"2011-03-25T14:49:56Z","shared":"no","toread":"no","tags":"example .private"},
+{"href":"https:\/\/example.org\/","description":"Untagged","extended":"","meta":"5b3d5bc0a1bd5d9fe1a0fbd0ac1e0d4b","hash":"a6bf1757fff057f266b697df9cf176fd","time":"2010-01-01T00:00:00Z","shared":"yes","toread":"no","tags":""}]`
+
+func TestBackup(t *testing.T) {
+	posts, err := ReadBackup(strings.NewReader(testBackup))
+	if err != nil {
+		t.Fatalf("Error from ReadBackup: %v", err)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("Wanted 2 posts, Got %d", len(posts))
+	}
+	want := Post{
+		XMLName:     xml.Name{Local: "post"},
+		Url:         "https://example.com/",
+		Description: "Example",
+		Hash:        "c984d06aafbecf6bc55569f964148ea3",
+		Tags:        postTags{"example", ".private"},
+		Extended:    "An example",
+		Date:        time.Date(2011, time.March, 25, 14, 49, 56, 0, time.UTC),
+		Shared:      No,
+		ToRead:      No,
+		Meta:        "92959a96fd69146c5fe7cbde6e5720f2",
+	}
+	if !reflect.DeepEqual(want, posts[0]) {
+		t.Errorf("Want %#v\nGot  %#v", want, posts[0])
+	}
+
+	var buf bytes.Buffer
+	err = WriteBackup(&buf, posts)
+	if err != nil {
+		t.Fatalf("Error from WriteBackup: %v", err)
+	}
+	got, err := ReadBackup(&buf)
+	if err != nil {
+		t.Fatalf("Error reading written backup: %v", err)
+	}
+	if !reflect.DeepEqual(posts, got) {
+		t.Errorf("Backup did not round trip.\nWant %#v\nGot  %#v", posts, got)
+	}
+}
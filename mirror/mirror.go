@@ -0,0 +1,285 @@
+// Package mirror keeps a local copy of a Pinboard account's posts, tags and notes
+// on disk, so tools can query bookmarks as often as they like without running into
+// the API's rate limits.
+//
+// Sync brings the copy up to date cheaply: it does nothing when posts/update
+// reports no change since the last sync, and otherwise only downloads posts
+// dated since then. Because that misses deletions, edits to older posts and posts
+// added with an older date (such as those written by pinboard.Restore), a full
+// download replaces the copy every FullSyncInterval, and on the Sync after one
+// that found the account changed but no new or edited recent posts. Each Sync
+// calls posts/all at most once, to stay within its rate limit. FullSync forces a
+// full download.
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	pinboard "github.com/zoni/go-pinboard"
+)
+
+// DefaultFullSyncInterval is how often a Mirror re-downloads the whole account
+// unless configured otherwise.
+const DefaultFullSyncInterval = 24 * time.Hour
+
+// A Mirror is a local copy of a Pinboard account, stored as a JSON file. It is
+// safe for concurrent use.
+type Mirror struct {
+	// FullSyncInterval is the maximum time between two full downloads of the
+	// account. Open sets it to DefaultFullSyncInterval.
+	FullSyncInterval time.Duration
+
+	client pinboard.Client
+	path   string
+
+	mu    sync.RWMutex
+	state state
+}
+
+// state is what a Mirror stores on disk.
+type state struct {
+	Updated  time.Time       `json:"updated"`
+	FullSync time.Time       `json:"full_sync"`
+	Posts    []pinboard.Post `json:"posts"`
+	Tags     []pinboard.Tag  `json:"tags"`
+	Notes    []pinboard.Note `json:"notes"`
+}
+
+// Open returns a Mirror of the account c is authenticated as, stored in the file
+// at path. If the file exists its contents are loaded, otherwise the Mirror starts
+// out empty and the first Sync downloads the whole account.
+func Open(c pinboard.Client, path string) (*Mirror, error) {
+	m := &Mirror{
+		FullSyncInterval: DefaultFullSyncInterval,
+		client:           c,
+		path:             path,
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read mirror: %w", err)
+	}
+	err = json.Unmarshal(b, &m.state)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse mirror %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Sync updates the mirror from the account and saves it to disk.
+func (m *Mirror) Sync(ctx context.Context) error {
+	return m.sync(ctx, false)
+}
+
+// FullSync downloads the whole account into the mirror and saves it to disk,
+// regardless of FullSyncInterval. Use it after changes an incremental Sync can't
+// see, such as restoring posts with their original dates.
+func (m *Mirror) FullSync(ctx context.Context) error {
+	return m.sync(ctx, true)
+}
+
+func (m *Mirror) sync(ctx context.Context, full bool) error {
+	m.mu.RLock()
+	st := m.state
+	m.mu.RUnlock()
+
+	updated, err := m.client.PostsUpdatedContext(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to check for updates: %w", err)
+	}
+
+	full = full || st.FullSync.IsZero() || time.Since(st.FullSync) >= m.FullSyncInterval
+	if !full && !updated.After(st.Updated) {
+		return nil
+	}
+
+	if !full {
+		recent, err := m.client.PostsAllContext(ctx, pinboard.PostsAllFilter{From: st.Updated, Meta: true})
+		if err != nil {
+			return fmt.Errorf("Failed to download recent posts: %w", err)
+		}
+		recent = changed(st.Posts, recent)
+		if len(recent) == 0 {
+			// The account changed, but not by adding or editing recently
+			// dated posts, so the change can only be found with a full
+			// download. It is left to the next Sync, which would otherwise
+			// hit the posts/all rate limit.
+			st.FullSync = time.Time{}
+		}
+		st.Posts = merge(st.Posts, recent)
+	}
+	if full {
+		st.Posts, err = m.client.PostsAllContext(ctx, pinboard.PostsAllFilter{Meta: true})
+		if err != nil {
+			return fmt.Errorf("Failed to download posts: %w", err)
+		}
+		st.FullSync = time.Now()
+	}
+
+	st.Tags, err = m.client.TagsGetContext(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to download tags: %w", err)
+	}
+
+	st.Notes, err = m.syncNotes(ctx, st.Notes)
+	if err != nil {
+		return err
+	}
+
+	st.Updated = updated
+	err = m.save(st)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.state = st
+	m.mu.Unlock()
+	return nil
+}
+
+// changed returns the posts in recent that are not in posts, or whose Meta
+// signature differs. Since fromdt is inclusive, recent usually repeats the newest
+// post already mirrored.
+func changed(posts, recent []pinboard.Post) []pinboard.Post {
+	meta := map[string]string{}
+	for _, pp := range posts {
+		meta[pp.Url] = pp.Meta
+	}
+	var out []pinboard.Post
+	for _, pp := range recent {
+		if m, ok := meta[pp.Url]; !ok || m != pp.Meta {
+			out = append(out, pp)
+		}
+	}
+	return out
+}
+
+// merge returns posts with every post in recent added or replaced, most recent
+// first.
+func merge(posts, recent []pinboard.Post) []pinboard.Post {
+	byURL := map[string]int{}
+	merged := append([]pinboard.Post(nil), posts...)
+	for i, pp := range merged {
+		byURL[pp.Url] = i
+	}
+	for _, pp := range recent {
+		if i, ok := byURL[pp.Url]; ok {
+			merged[i] = pp
+			continue
+		}
+		byURL[pp.Url] = len(merged)
+		merged = append(merged, pp)
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Date.After(merged[j].Date)
+	})
+	return merged
+}
+
+// syncNotes lists the account's notes and downloads the text of those that are
+// new or changed since the last sync.
+func (m *Mirror) syncNotes(ctx context.Context, old []pinboard.Note) ([]pinboard.Note, error) {
+	known := map[string]pinboard.Note{}
+	for _, n := range old {
+		known[n.ID] = n
+	}
+
+	list, err := m.client.NotesListContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list notes: %w", err)
+	}
+
+	notes := make([]pinboard.Note, 0, len(list))
+	for _, n := range list {
+		if k, ok := known[n.ID]; ok && k.Hash == n.Hash {
+			notes = append(notes, k)
+			continue
+		}
+		full, err := m.client.NotesGetContext(ctx, n.ID)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to download note %s: %w", n.ID, err)
+		}
+		// Only the notes list returns creation and update times
+		full.Created = n.Created
+		full.Updated = n.Updated
+		notes = append(notes, full)
+	}
+	return notes, nil
+}
+
+// save atomically replaces the mirror file with st.
+func (m *Mirror) save(st state) error {
+	b, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("Failed to encode mirror: %w", err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(m.path), filepath.Base(m.path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("Failed to save mirror: %w", err)
+	}
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), m.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("Failed to save mirror: %w", err)
+	}
+	return nil
+}
+
+// Updated returns the account's last update time as of the last sync.
+func (m *Mirror) Updated() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.state.Updated
+}
+
+// Posts returns every post in the mirror, most recent first.
+func (m *Mirror) Posts() []pinboard.Post {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]pinboard.Post(nil), m.state.Posts...)
+}
+
+// Post returns the post with the given URL, if the mirror has one.
+func (m *Mirror) Post(url string) (pinboard.Post, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, pp := range m.state.Posts {
+		if pp.Url == url {
+			return pp, true
+		}
+	}
+	return pinboard.Post{}, false
+}
+
+// Tags returns the account's tags and their counts.
+func (m *Mirror) Tags() []pinboard.Tag {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]pinboard.Tag(nil), m.state.Tags...)
+}
+
+// Notes returns the account's notes, including their text.
+func (m *Mirror) Notes() []pinboard.Note {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]pinboard.Note(nil), m.state.Notes...)
+}
@@ -0,0 +1,117 @@
+package mirror
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pinboard "github.com/zoni/go-pinboard"
+	"github.com/zoni/go-pinboard/pinboardtest"
+)
+
+func TestMirrorSync(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mirror.json")
+
+	// Posts are dated relative to now, whole seconds apart, so which of them
+	// fall after the account's update time doesn't depend on timing
+	now := time.Now().UTC().Truncate(time.Second)
+	s := pinboardtest.NewServer(pinboardtest.WithPosts(
+		pinboard.Post{Url: "https://example.com/1", Description: "One", Tags: []string{"a"}, Date: now.Add(-time.Hour)},
+	))
+	defer s.Close()
+	s.AddNote("Hello", "Hello, world")
+	p := s.Client()
+	ctx := context.Background()
+
+	m, err := Open(p, path)
+	if err != nil {
+		t.Fatalf("Error opening mirror: %v", err)
+	}
+	err = m.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Error from first Sync: %v", err)
+	}
+	if len(m.Posts()) != 1 || len(m.Tags()) != 1 || len(m.Notes()) != 1 || m.Notes()[0].Text != "Hello, world" {
+		t.Errorf("Wanted 1 post, tag and note, Got %v, %v, %v", m.Posts(), m.Tags(), m.Notes())
+	}
+
+	// Nothing changed, so posts/all isn't called again
+	err = m.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Error from second Sync: %v", err)
+	}
+	if n := s.Calls("posts/all"); n != 1 {
+		t.Errorf("Wanted 1 call to posts/all, Got %d", n)
+	}
+
+	// A new post is fetched incrementally, and the mirror survives reopening
+	err = p.PostsAdd(pinboard.Post{Url: "https://example.com/2", Description: "Two", Date: now.Add(time.Hour)}, false)
+	if err != nil {
+		t.Fatalf("Error from PostsAdd: %v", err)
+	}
+	before := s.Calls("posts/all")
+	err = m.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Error from third Sync: %v", err)
+	}
+	m, err = Open(p, path)
+	if err != nil {
+		t.Fatalf("Error reopening mirror: %v", err)
+	}
+	if pp, ok := m.Post("https://example.com/2"); !ok || pp.Description != "Two" {
+		t.Errorf("Wanted the new post in the mirror, Got %v", m.Posts())
+	}
+	if len(m.Posts()) != 2 {
+		t.Errorf("Wanted 2 posts, Got %v", m.Posts())
+	}
+	if n := s.Calls("posts/all") - before; n != 1 {
+		t.Errorf("Wanted 1 incremental posts/all call, Got %d", n)
+	}
+
+	// A post added with an old date isn't found incrementally, so the next
+	// Sync falls back to a full download
+	err = p.PostsAdd(pinboard.Post{Url: "https://example.com/old", Description: "Old", Date: now.Add(-48 * time.Hour)}, false)
+	if err != nil {
+		t.Fatalf("Error from PostsAdd: %v", err)
+	}
+	before = s.Calls("posts/all")
+	err = m.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Error from Sync after backdated post: %v", err)
+	}
+	if _, ok := m.Post("https://example.com/old"); ok {
+		t.Errorf("Wanted the backdated post missed by an incremental Sync, Got %v", m.Posts())
+	}
+	err = m.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Error from fallback Sync: %v", err)
+	}
+	if _, ok := m.Post("https://example.com/old"); !ok || len(m.Posts()) != 3 {
+		t.Errorf("Wanted the backdated post in the mirror, Got %v", m.Posts())
+	}
+	if n := s.Calls("posts/all") - before; n != 2 {
+		t.Errorf("Wanted one posts/all call per Sync, Got %d", n)
+	}
+
+	// Deletions are only picked up by a full sync
+	err = p.PostsDelete("https://example.com/1")
+	if err != nil {
+		t.Fatalf("Error from PostsDelete: %v", err)
+	}
+	m.FullSyncInterval = 0
+	err = m.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Error from full Sync: %v", err)
+	}
+	if _, ok := m.Post("https://example.com/1"); ok || len(m.Posts()) != 2 {
+		t.Errorf("Wanted the deleted post gone, Got %v", m.Posts())
+	}
+}
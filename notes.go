@@ -1,9 +1,9 @@
 package pinboard
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
-	"net/url"
 	"regexp"
 )
 
@@ -29,19 +29,24 @@ type Note struct {
 
 // NotesList returns a list of the user's notes.
 func (p *Pinboard) NotesList() ([]Note, error) {
-	u, err := url.Parse(apiBase + "notes/list")
+	return p.NotesListContext(context.Background())
+}
+
+// NotesListContext is like NotesList but uses ctx for the API request.
+func (p *Pinboard) NotesListContext(ctx context.Context) ([]Note, error) {
+	u, err := p.endpoint("notes/list")
 	if err != nil {
-		return []Note{}, fmt.Errorf("Failed to parse Notes list API URL: %v", err)
+		return []Note{}, fmt.Errorf("Failed to parse Notes list API URL: %w", err)
 	}
 
-	resp, err := p.get(u)
+	resp, err := p.get(ctx, u)
 	if err != nil {
 		return []Note{}, err
 	}
 
-	tmp, err := parseResponse(resp, &notes{})
+	tmp, err := p.parse(resp, &notes{})
 	if err != nil {
-		return []Note{}, fmt.Errorf("Failed to parse Notes response: %v", err)
+		return []Note{}, fmt.Errorf("Failed to parse Notes response: %w", err)
 	}
 	no := tmp.(*notes)
 	return no.Notes, err
@@ -49,23 +54,28 @@ func (p *Pinboard) NotesList() ([]Note, error) {
 
 // NotesGet returns a single Note.
 func (p *Pinboard) NotesGet(noteID string) (Note, error) {
+	return p.NotesGetContext(context.Background(), noteID)
+}
+
+// NotesGetContext is like NotesGet but uses ctx for the API request.
+func (p *Pinboard) NotesGetContext(ctx context.Context, noteID string) (Note, error) {
 	if m, _ := regexp.Match("[a-z0-9]{20}", []byte(noteID)); !m {
-		return Note{}, fmt.Errorf("Note ID must be a 20 character sha1 hash")
+		return Note{}, validationErrorf("Note ID must be a 20 character sha1 hash")
 	}
 
-	u, err := url.Parse(apiBase + "notes/" + noteID)
+	u, err := p.endpoint("notes/" + noteID)
 	if err != nil {
-		return Note{}, fmt.Errorf("Failed to parse note URL: %v", err)
+		return Note{}, fmt.Errorf("Failed to parse note URL: %w", err)
 	}
 
-	resp, err := p.get(u)
+	resp, err := p.get(ctx, u)
 	if err != nil {
-		return Note{}, fmt.Errorf("Error getting note: %v", err)
+		return Note{}, fmt.Errorf("Error getting note: %w", err)
 	}
 
-	tmp, err := parseResponse(resp, &Note{})
+	tmp, err := p.parse(resp, &Note{})
 	if err != nil {
-		return Note{}, fmt.Errorf("Failed to parse Note response: %v", err)
+		return Note{}, fmt.Errorf("Failed to parse Note response: %w", err)
 	}
 	note := tmp.(*Note)
 	return *note, err
@@ -0,0 +1,133 @@
+package pinboard
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// A PostsPager walks every post matched by a PostsAllFilter one page at a time,
+// using the filter's Start and Results parameters. Pages are requested at most
+// once per Interval, so a large account can be downloaded without tripping the
+// posts/all rate limit. Paging ends when a page shorter than the page size is
+// returned.
+//
+// An interrupted walk can be resumed by saving Offset and passing it as the
+// filter's Start to a new pager. When Each stops inside a page, Offset points at
+// the first post of that page fn did not finish, so no post is skipped.
+type PostsPager struct {
+	// Interval is the minimum time between two page requests. NewPostsPager
+	// sets it to Pinboard's documented posts/all limit of 5 minutes.
+	Interval time.Duration
+
+	// Progress, if set, is called after every page with the offset of the next
+	// page and the number of posts fetched by this pager so far.
+	Progress func(offset, fetched int)
+
+	c        Client
+	filter   PostsAllFilter
+	pageSize int
+	fetched  int
+	done     bool
+	last     time.Time
+
+	// page holds the posts of the current page Each has not finished yet.
+	page []Post
+}
+
+// NewPostsPager returns a pager over the posts c returns for apf, starting at
+// apf.Start, with pageSize posts per page. If pageSize is zero apf.Results is
+// used, or 100 if that is zero too.
+func NewPostsPager(c Client, apf PostsAllFilter, pageSize int) *PostsPager {
+	if pageSize < 1 {
+		pageSize = apf.Results
+	}
+	if pageSize < 1 {
+		pageSize = 100
+	}
+	apf.Results = pageSize
+	return &PostsPager{
+		Interval: DefaultRateLimit.Endpoints["posts/all"],
+		c:        c,
+		filter:   apf,
+		pageSize: pageSize,
+	}
+}
+
+// Offset returns the offset of the first post that has not been returned by Next
+// or finished by the fn passed to Each.
+func (pg *PostsPager) Offset() int {
+	return pg.filter.Start - len(pg.page)
+}
+
+// Done reports whether every post has been returned.
+func (pg *PostsPager) Done() bool {
+	return pg.done && len(pg.page) == 0
+}
+
+// Next waits for the pager's Interval to pass since the previous page, then
+// returns the next page. If Each stopped inside a page, the posts of that page it
+// did not finish are returned first, without waiting. It returns no posts once
+// Done reports true.
+func (pg *PostsPager) Next(ctx context.Context) ([]Post, error) {
+	if len(pg.page) > 0 {
+		pp := pg.page
+		pg.page = nil
+		return pp, nil
+	}
+	if pg.done {
+		return nil, nil
+	}
+
+	if !pg.last.IsZero() {
+		t := time.NewTimer(time.Until(pg.last.Add(pg.Interval)))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+
+	pp, err := pg.c.PostsAllContext(ctx, pg.filter)
+	pg.last = time.Now()
+	if err != nil {
+		return nil, err
+	}
+
+	pg.filter.Start += len(pp)
+	pg.fetched += len(pp)
+	if len(pp) < pg.pageSize {
+		pg.done = true
+	}
+	if pg.Progress != nil {
+		pg.Progress(pg.filter.Start, pg.fetched)
+	}
+	return pp, nil
+}
+
+// Each calls fn for every remaining post. If fn returns an error paging stops
+// and the error is returned, unless it is ErrStopIteration, in which case Each
+// returns nil. A post counts as finished once fn returns nil for it, so after
+// an error Offset points at the post fn failed or stopped on, and calling Each
+// again or resuming from Offset hands that post out again.
+func (pg *PostsPager) Each(ctx context.Context, fn func(Post) error) error {
+	for !pg.Done() {
+		pp, err := pg.Next(ctx)
+		if err != nil {
+			return err
+		}
+		pg.page = pp
+		for len(pg.page) > 0 {
+			err = fn(pg.page[0])
+			if errors.Is(err, ErrStopIteration) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			pg.page = pg.page[1:]
+		}
+	}
+	return nil
+}
@@ -5,34 +5,151 @@
 package pinboard
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 )
 
 var apiBase = "https://api.pinboard.in/v1/"
 
 // A Pinboard represents a client for the Pinboard V1 API. Authentication can use
 // passwords or tokens. Token auth is recommended for good password hygiene.
+//
+// A Pinboard can be declared as a struct literal, in which case it talks to the
+// public API with http.DefaultClient, or created with New to customize the HTTP
+// client, base URL and user agent.
 type Pinboard struct {
 	User     string
 	Password string
 	Token    string
+
+	client    *http.Client
+	baseURL   string
+	userAgent string
+	limiter   *rateLimiter
+	retry     *RetryPolicy
+	json      bool
+}
+
+// An Option configures a Pinboard created by New.
+type Option func(*Pinboard)
+
+// New returns a Pinboard configured with the given options.
+func New(opts ...Option) *Pinboard {
+	p := &Pinboard{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// WithToken authenticates as user with an API token (the part after the colon
+// on the Pinboard settings page).
+func WithToken(user, token string) Option {
+	return func(p *Pinboard) {
+		p.User = user
+		p.Token = token
+	}
+}
+
+// WithPassword authenticates as user with HTTP basic auth.
+func WithPassword(user, password string) Option {
+	return func(p *Pinboard) {
+		p.User = user
+		p.Password = password
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for API requests. Timeouts, proxies
+// and the like are configured on the client.
+func WithHTTPClient(c *http.Client) Option {
+	return func(p *Pinboard) {
+		p.client = c
+	}
+}
+
+// WithTransport sets the transport of the HTTP client used for API requests. If
+// a client was set with WithHTTPClient, a copy of it is made so the original is
+// left untouched.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(p *Pinboard) {
+		c := &http.Client{}
+		if p.client != nil {
+			*c = *p.client
+		}
+		c.Transport = rt
+		p.client = c
+	}
+}
+
+// WithBaseURL points the client at a different API root, such as a local test
+// server. The default is https://api.pinboard.in/v1/.
+func WithBaseURL(base string) Option {
+	return func(p *Pinboard) {
+		if !strings.HasSuffix(base, "/") {
+			base += "/"
+		}
+		p.baseURL = base
+	}
+}
+
+// WithJSON makes the client request and decode the API's JSON format instead of XML.
+// JSON responses are smaller and faster to decode, which matters for large posts/all
+// downloads. Decoded values are identical to those of the XML format.
+func WithJSON() Option {
+	return func(p *Pinboard) {
+		p.json = true
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every API request.
+func WithUserAgent(ua string) Option {
+	return func(p *Pinboard) {
+		p.userAgent = ua
+	}
+}
+
+// endpoint returns the URL for the given API method, relative to the base URL.
+func (p *Pinboard) endpoint(method string) (*url.URL, error) {
+	base := apiBase
+	if p != nil && len(p.baseURL) > 0 {
+		base = p.baseURL
+	}
+	return url.Parse(base + method)
+}
+
+// method returns the API method (ex: "posts/all") that u points at.
+func (p *Pinboard) method(u *url.URL) string {
+	base, err := p.endpoint("")
+	if err != nil {
+		return u.Path
+	}
+	return strings.TrimPrefix(u.Path, base.Path)
+}
+
+func (p *Pinboard) httpClient() *http.Client {
+	if p.client != nil {
+		return p.client
+	}
+	return http.DefaultClient
 }
 
 func (p *Pinboard) authQuery(u *url.URL) error {
 	if p == nil {
-		return fmt.Errorf("Pinboard object has not been initialized")
+		return validationErrorf("Pinboard object has not been initialized")
 	}
 	if len(p.User) < 1 {
-		return fmt.Errorf("Pinboard requires a Username and either a Password or Token for authentication")
+		return validationErrorf("Pinboard requires a Username and either a Password or Token for authentication")
 	}
 
 	if len(p.Token) < 1 {
 		if len(p.Password) < 1 {
-			return fmt.Errorf("Pinboard requires either a Password or Token for authentication")
+			return validationErrorf("Pinboard requires either a Password or Token for authentication")
 		}
 		u.User = url.UserPassword(p.User, p.Password)
 		return nil
@@ -44,31 +161,74 @@ func (p *Pinboard) authQuery(u *url.URL) error {
 	return nil
 }
 
-// Retrieve an API response for the given URL. Auth is added to the URL object here
-func (p *Pinboard) get(u *url.URL) (*http.Response, error) {
+// Retrieve an API response for the given URL. Auth is added to the URL object here.
+// The request is bound to ctx, so cancelling it also aborts reading the response body
+// in parseResponse. Failed requests are retried according to the client's RetryPolicy.
+func (p *Pinboard) get(ctx context.Context, u *url.URL) (*http.Response, error) {
+	if p != nil && p.json {
+		q := u.Query()
+		q.Set("format", "json")
+		u.RawQuery = q.Encode()
+	}
+
 	err := p.authQuery(u)
 	if err != nil {
-		return nil, fmt.Errorf("Pinboard failed to generate an auth query param: %v", err)
+		return nil, fmt.Errorf("Pinboard failed to generate an auth query param: %w", err)
 	}
 
-	resp, err := http.Get(u.String())
-	if err != nil {
-		return nil, err
+	method := p.method(u)
+	attempts := 1
+	if p.retry != nil && (p.retry.RetryWrites || !writeMethods[method]) {
+		attempts = p.retry.MaxAttempts
 	}
 
-	if resp.StatusCode >= 400 {
-		if resp.StatusCode == 401 {
-			return nil, fmt.Errorf("Error from Pinboard API: Authentication Failed")
+	for attempt := 1; ; attempt++ {
+		resp, err := p.do(ctx, u, method)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode < 400 {
+			return resp, nil
 		}
+
 		resp_body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
 		if err != nil {
-			return nil, fmt.Errorf("Error reading error body: %v", err)
+			return nil, fmt.Errorf("Error reading error body: %w", err)
+		}
+
+		if attempt >= attempts || !retryable(resp.StatusCode) {
+			return nil, &APIError{StatusCode: resp.StatusCode, Body: string(resp_body), Endpoint: method}
+		}
+
+		t := time.NewTimer(p.retry.delay(attempt, resp.Header.Get("Retry-After")))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
 		}
-		resp.Body.Close()
-		return nil, fmt.Errorf("Error from Pinboard API (%d): %v", resp.StatusCode, string(resp_body))
 	}
+}
 
-	return resp, err
+// do makes a single request for u, waiting for the rate limiter first.
+func (p *Pinboard) do(ctx context.Context, u *url.URL, method string) (*http.Response, error) {
+	if p.limiter != nil {
+		err := p.limiter.wait(ctx, method)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.userAgent) > 0 {
+		req.Header.Set("User-Agent", p.userAgent)
+	}
+
+	return p.httpClient().Do(req)
 }
 
 // parseResponse is a helper for parsing XML into different types. The use of the
@@ -87,7 +247,47 @@ func parseResponse(resp *http.Response, to interface{}) (interface{}, error) {
 	return to, nil
 }
 
+// parse decodes resp in the client's wire format. For JSON, to must implement
+// jsonResponse.
+func (p *Pinboard) parse(resp *http.Response, to interface{}) (interface{}, error) {
+	if !p.json {
+		return parseResponse(resp, to)
+	}
+
+	resp_body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	err = to.(jsonResponse).decodeJSON(resp_body)
+	if err != nil {
+		return nil, err
+	}
+	return to, nil
+}
+
 type result struct {
 	XMLName xml.Name `xml:"result" json:"-"`
+	Code    string   `xml:"code,attr"`
 	Result  string   `xml:",innerxml"`
 }
+
+// checkResult parses the result returned by the write methods. Some methods send
+// the result code as an attribute (<result code="done" />), others as the element's
+// content (<result>done</result>).
+func (p *Pinboard) checkResult(resp *http.Response, method string) error {
+	tmp, err := p.parse(resp, &result{})
+	if err != nil {
+		return fmt.Errorf("Failed to parse %s result: %w", method, err)
+	}
+	res := tmp.(*result)
+
+	code := res.Code
+	if len(code) < 1 {
+		code = strings.TrimSpace(res.Result)
+	}
+	if code == "done" {
+		return nil
+	}
+	return &ResultError{Code: code, Endpoint: method}
+}
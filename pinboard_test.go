@@ -1,6 +1,7 @@
 package pinboard
 
 import (
+	"context"
 	"encoding/xml"
 	"errors"
 	"fmt"
@@ -9,6 +10,7 @@ import (
 	"net/url"
 	"reflect"
 	"testing"
+	"time"
 )
 
 var p1 = Pinboard{
@@ -125,3 +127,61 @@ func TestParseResponse(t *testing.T) {
 		t.Errorf("testBooks did not parse as expected")
 	}
 }
+
+func TestGetContextCancel(t *testing.T) {
+	done := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer s.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	u, _ := url.Parse(s.URL + "/posts/all")
+	_, err := p2.get(ctx, u)
+	if err == nil {
+		t.Fatal("Did not get an error from a cancelled request")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wanted context.DeadlineExceeded, Got %v", err)
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	var gotUA, gotPath, gotAuth string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUA = r.Header.Get("User-Agent")
+		gotPath = r.URL.Path
+		gotAuth = r.URL.Query().Get("auth_token")
+		fmt.Fprint(w, `<result>6D3F1921A2C33D82EA1</result>`)
+	}))
+	defer s.Close()
+
+	p := New(
+		WithToken("drags", "AC1638B3E618FD194CA0"),
+		WithBaseURL(s.URL+"/v1"),
+		WithHTTPClient(s.Client()),
+		WithUserAgent("go-pinboard-test"),
+	)
+	got, err := p.UserSecret()
+	if err != nil {
+		t.Fatalf("Error from UserSecret: %v", err)
+	}
+	if got != "6D3F1921A2C33D82EA1" {
+		t.Errorf("Wanted secret 6D3F1921A2C33D82EA1, Got %s", got)
+	}
+	if gotPath != "/v1/user/secret" {
+		t.Errorf("Wanted path /v1/user/secret, Got %s", gotPath)
+	}
+	if gotUA != "go-pinboard-test" {
+		t.Errorf("Wanted User-Agent go-pinboard-test, Got %s", gotUA)
+	}
+	if gotAuth != "drags:AC1638B3E618FD194CA0" {
+		t.Errorf("Wanted auth_token drags:AC1638B3E618FD194CA0, Got %s", gotAuth)
+	}
+}
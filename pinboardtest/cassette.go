@@ -0,0 +1,208 @@
+package pinboardtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// An Interaction is a recorded API request and its response. Credentials are
+// scrubbed before recording: auth_token is dropped from Query, request headers
+// (which carry basic auth) are not recorded, and any token or password found in
+// the response Body is replaced with "REDACTED".
+type Interaction struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// recordedHeaders are the response headers the pinboard package looks at.
+var recordedHeaders = []string{"Content-Type", "Retry-After"}
+
+// normalizeQuery returns the query with auth_token removed and keys sorted, so
+// requests match regardless of credentials and parameter order.
+func normalizeQuery(q url.Values) string {
+	n := url.Values{}
+	for k, v := range q {
+		if k != "auth_token" {
+			n[k] = v
+		}
+	}
+	return n.Encode()
+}
+
+func (i Interaction) matches(req *http.Request) bool {
+	return i.Method == req.Method && i.Path == req.URL.Path && i.Query == normalizeQuery(req.URL.Query())
+}
+
+// A Recorder is an http.RoundTripper that passes requests on to a transport and
+// records every interaction, for saving as a cassette with Save.
+type Recorder struct {
+	transport http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// NewRecorder returns a Recorder sending requests through rt, or through
+// http.DefaultTransport if rt is nil. Use it with pinboard.WithTransport.
+func NewRecorder(rt http.RoundTripper) *Recorder {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &Recorder{transport: rt}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	i := Interaction{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  normalizeQuery(req.URL.Query()),
+		Status: resp.StatusCode,
+		Header: http.Header{},
+		Body:   scrub(req, string(body)),
+	}
+	for _, h := range recordedHeaders {
+		if v := resp.Header.Get(h); len(v) > 0 {
+			i.Header.Set(h, v)
+		}
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, i)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// scrub removes the credentials used by req from body.
+func scrub(req *http.Request, body string) string {
+	var secrets []string
+	if token := req.URL.Query().Get("auth_token"); len(token) > 0 {
+		if i := strings.Index(token, ":"); i >= 0 {
+			secrets = append(secrets, token[i+1:])
+		}
+	}
+	if _, password, ok := req.BasicAuth(); ok {
+		secrets = append(secrets, password)
+	}
+	for _, s := range secrets {
+		if len(s) > 0 {
+			body = strings.Replace(body, s, "REDACTED", -1)
+		}
+	}
+	return body
+}
+
+// Interactions returns the interactions recorded so far.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// Save writes the recorded interactions to a cassette file at path.
+func (r *Recorder) Save(path string) error {
+	b, err := json.MarshalIndent(r.Interactions(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// A Replayer is an http.RoundTripper that answers requests from recorded
+// interactions instead of the network. Requests match an interaction on method,
+// path and normalized query, and each interaction is replayed once, in recorded
+// order. A request without a matching interaction fails with an error naming it.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer returns a Replayer for the given interactions.
+func NewReplayer(interactions []Interaction) *Replayer {
+	return &Replayer{
+		interactions: interactions,
+		used:         make([]bool, len(interactions)),
+	}
+}
+
+// LoadReplayer returns a Replayer for the cassette file at path.
+func LoadReplayer(path string) (*Replayer, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var interactions []Interaction
+	err = json.Unmarshal(b, &interactions)
+	if err != nil {
+		return nil, fmt.Errorf("pinboardtest: failed to parse cassette %s: %w", path, err)
+	}
+	return NewReplayer(interactions), nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for n, i := range r.interactions {
+		if r.used[n] || !i.matches(req) {
+			continue
+		}
+		r.used[n] = true
+
+		header := http.Header{}
+		for k, v := range i.Header {
+			header[k] = v
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", i.Status, http.StatusText(i.Status)),
+			StatusCode:    i.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(strings.NewReader(i.Body)),
+			ContentLength: int64(len(i.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("pinboardtest: no recorded interaction matches %s %s?%s", req.Method, req.URL.Path, normalizeQuery(req.URL.Query()))
+}
+
+// Unused returns the interactions that have not been replayed yet. Tests can
+// check it is empty to make sure every recorded request was made.
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var unused []Interaction
+	for n, i := range r.interactions {
+		if !r.used[n] {
+			unused = append(unused, i)
+		}
+	}
+	return unused
+}
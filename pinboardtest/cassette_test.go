@@ -0,0 +1,68 @@
+package pinboardtest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pinboard "github.com/zoni/go-pinboard"
+)
+
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pinboardtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cassette.json")
+
+	s := NewServer()
+	rec := NewRecorder(s.srv.Client().Transport)
+	p := s.Client(pinboard.WithTransport(rec))
+	err = p.PostsAdd(pinboard.Post{Url: "https://example.com/", Description: "Example"}, false)
+	if err != nil {
+		t.Fatalf("Error from PostsAdd: %v", err)
+	}
+	want, err := p.UserApiToken()
+	if err != nil {
+		t.Fatalf("Error from UserApiToken: %v", err)
+	}
+	s.Close()
+
+	err = rec.Save(path)
+	if err != nil {
+		t.Fatalf("Error saving cassette: %v", err)
+	}
+	b, _ := ioutil.ReadFile(path)
+	if strings.Contains(string(b), DefaultToken) {
+		t.Errorf("Cassette contains the API token:\n%s", b)
+	}
+
+	rep, err := LoadReplayer(path)
+	if err != nil {
+		t.Fatalf("Error loading cassette: %v", err)
+	}
+	p = pinboard.New(
+		pinboard.WithToken("someone", "else"),
+		pinboard.WithBaseURL(s.URL),
+		pinboard.WithTransport(rep),
+	)
+	err = p.PostsAdd(pinboard.Post{Url: "https://example.com/", Description: "Example"}, false)
+	if err != nil {
+		t.Errorf("Error from replayed PostsAdd: %v", err)
+	}
+	got, err := p.UserApiToken()
+	if err != nil || want != DefaultToken || got != "REDACTED" {
+		t.Errorf("Wanted a redacted token, Got %s (%v)", got, err)
+	}
+	if len(rep.Unused()) != 0 {
+		t.Errorf("Wanted all interactions replayed, Got %v unused", rep.Unused())
+	}
+
+	_, err = p.UserSecret()
+	if err == nil || !strings.Contains(err.Error(), "no recorded interaction matches GET /v1/user/secret") {
+		t.Errorf("Wanted an unmatched request error, Got %v", err)
+	}
+}
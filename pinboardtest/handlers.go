@@ -0,0 +1,542 @@
+package pinboardtest
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	method := strings.TrimPrefix(r.URL.Path, "/v1/")
+	q := r.URL.Query()
+	rw := responder{w: w, json: q.Get("format") == "json"}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[method]++
+
+	if !s.authorized(r) {
+		http.Error(w, "401 Forbidden", http.StatusUnauthorized)
+		return
+	}
+
+	if s.interval > 0 {
+		now := time.Now()
+		if wait := s.lastCall.Add(s.interval).Sub(now); wait > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+			return
+		}
+		s.lastCall = now
+	}
+
+	switch method {
+	case "posts/update":
+		s.postsUpdate(rw)
+	case "posts/add":
+		s.postsAdd(rw, q)
+	case "posts/delete":
+		s.postsDelete(rw, q)
+	case "posts/get":
+		s.postsGet(rw, q)
+	case "posts/recent":
+		s.postsRecent(rw, q)
+	case "posts/dates":
+		s.postsDates(rw, q)
+	case "posts/all":
+		s.postsAll(rw, q)
+	case "posts/suggest":
+		s.postsSuggest(rw, q)
+	case "tags/get":
+		s.tagsGet(rw)
+	case "tags/delete":
+		s.tagsDelete(rw, q)
+	case "tags/rename":
+		s.tagsRename(rw, q)
+	case "notes/list":
+		s.notesList(rw)
+	case "user/secret":
+		rw.write(result{Result: s.secret}, map[string]string{"result": s.secret})
+	case "user/api_token":
+		rw.write(result{Result: s.token}, map[string]string{"result": s.token})
+	default:
+		if strings.HasPrefix(method, "notes/") {
+			s.notesGet(rw, strings.TrimPrefix(method, "notes/"))
+			return
+		}
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if token := r.URL.Query().Get("auth_token"); len(token) > 0 {
+		return token == s.user+":"+s.token
+	}
+	user, password, ok := r.BasicAuth()
+	return ok && len(s.password) > 0 && user == s.user && password == s.password
+}
+
+// responder writes a value as XML or JSON depending on the requested format.
+type responder struct {
+	w    http.ResponseWriter
+	json bool
+}
+
+func (rw responder) write(x, j interface{}) {
+	if rw.json {
+		rw.w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(rw.w).Encode(j)
+		return
+	}
+	rw.w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	fmt.Fprint(rw.w, xml.Header)
+	xml.NewEncoder(rw.w).Encode(x)
+}
+
+// code writes the result code used by the posts/* write methods.
+func (rw responder) code(code string) {
+	rw.write(result{Code: code}, map[string]string{"result_code": code})
+}
+
+// done writes the result used by the tags/* write methods.
+func (rw responder) done() {
+	rw.write(result{Result: "done"}, map[string]string{"result": "done"})
+}
+
+type result struct {
+	XMLName xml.Name `xml:"result"`
+	Code    string   `xml:"code,attr,omitempty"`
+	Result  string   `xml:",chardata"`
+}
+
+type xmlPost struct {
+	XMLName     xml.Name `xml:"post"`
+	Href        string   `xml:"href,attr"`
+	Time        string   `xml:"time,attr"`
+	Description string   `xml:"description,attr"`
+	Extended    string   `xml:"extended,attr"`
+	Tag         string   `xml:"tag,attr"`
+	Hash        string   `xml:"hash,attr"`
+	Meta        string   `xml:"meta,attr,omitempty"`
+	Shared      string   `xml:"shared,attr"`
+	ToRead      string   `xml:"toread,attr,omitempty"`
+}
+
+type jsonPost struct {
+	Href        string `json:"href"`
+	Description string `json:"description"`
+	Extended    string `json:"extended"`
+	Meta        string `json:"meta"`
+	Hash        string `json:"hash"`
+	Time        string `json:"time"`
+	Shared      string `json:"shared"`
+	ToRead      string `json:"toread"`
+	Tags        string `json:"tags"`
+}
+
+func renderPosts(pp []*post, meta bool) ([]xmlPost, []jsonPost) {
+	xp, jp := []xmlPost{}, []jsonPost{}
+	for _, p := range pp {
+		x := xmlPost{
+			Href:        p.url,
+			Time:        p.time.Format(time.RFC3339),
+			Description: p.description,
+			Extended:    p.extended,
+			Tag:         strings.Join(p.tags, " "),
+			Hash:        p.hash(),
+			Shared:      yesNo(p.shared),
+		}
+		if meta {
+			x.Meta = p.meta()
+		}
+		if p.toread {
+			x.ToRead = "yes"
+		}
+		xp = append(xp, x)
+		jp = append(jp, jsonPost{
+			Href:        x.Href,
+			Description: x.Description,
+			Extended:    x.Extended,
+			Meta:        x.Meta,
+			Hash:        x.Hash,
+			Time:        x.Time,
+			Shared:      x.Shared,
+			ToRead:      yesNo(p.toread),
+			Tags:        x.Tag,
+		})
+	}
+	return xp, jp
+}
+
+func (s *Server) writePosts(rw responder, pp []*post, meta bool, dt time.Time) {
+	xp, jp := renderPosts(pp, meta)
+	rw.write(struct {
+		XMLName xml.Name  `xml:"posts"`
+		User    string    `xml:"user,attr"`
+		Date    string    `xml:"dt,attr"`
+		Posts   []xmlPost `xml:"post"`
+	}{User: s.user, Date: dt.Format(time.RFC3339), Posts: xp}, map[string]interface{}{
+		"date":  dt.Format(time.RFC3339),
+		"user":  s.user,
+		"posts": jp,
+	})
+}
+
+func (s *Server) postsUpdate(rw responder) {
+	t := s.updated.Format(time.RFC3339)
+	rw.write(struct {
+		XMLName xml.Name `xml:"update"`
+		Time    string   `xml:"time,attr"`
+	}{Time: t}, map[string]string{"update_time": t})
+}
+
+func (s *Server) postsAdd(rw responder, q url.Values) {
+	u := q.Get("url")
+	if len(u) < 1 {
+		rw.code("missing url")
+		return
+	}
+	if len(q.Get("description")) < 1 {
+		rw.code("missing description")
+		return
+	}
+	if _, ok := s.posts[u]; ok && q.Get("replace") == "no" {
+		rw.code("item already exists")
+		return
+	}
+
+	p := &post{
+		url:         u,
+		description: q.Get("description"),
+		extended:    q.Get("extended"),
+		tags:        strings.Fields(strings.Replace(q.Get("tags"), ",", " ", -1)),
+		time:        time.Now().UTC().Truncate(time.Second),
+		shared:      q.Get("shared") != "no",
+		toread:      q.Get("toread") == "yes",
+	}
+	if dt := q.Get("dt"); len(dt) > 0 {
+		t, err := time.Parse(time.RFC3339, dt)
+		if err != nil {
+			rw.code("invalid dt")
+			return
+		}
+		p.time = t.UTC()
+	}
+
+	s.posts[u] = p
+	s.touch()
+	rw.code("done")
+}
+
+func (s *Server) postsDelete(rw responder, q url.Values) {
+	u := q.Get("url")
+	if _, ok := s.posts[u]; !ok {
+		rw.code("item not found")
+		return
+	}
+	delete(s.posts, u)
+	s.touch()
+	rw.code("done")
+}
+
+func (s *Server) postsGet(rw responder, q url.Values) {
+	tags := q["tag"]
+	u := q.Get("url")
+	all := s.sortedPosts()
+
+	var day string
+	if dt := q.Get("dt"); len(dt) > 0 {
+		day = dt
+	} else if len(u) < 1 && len(all) > 0 {
+		day = all[0].time.Format("2006-01-02")
+	}
+
+	var pp []*post
+	for _, p := range all {
+		if len(u) > 0 && p.url != u {
+			continue
+		}
+		if len(day) > 0 && p.time.Format("2006-01-02") != day {
+			continue
+		}
+		if p.hasTags(tags) {
+			pp = append(pp, p)
+		}
+	}
+
+	dt := s.updated
+	if len(pp) > 0 {
+		dt = pp[0].time
+	}
+	s.writePosts(rw, pp, q.Get("meta") == "yes", dt)
+}
+
+func (s *Server) postsRecent(rw responder, q url.Values) {
+	count := 15
+	if c, err := strconv.Atoi(q.Get("count")); err == nil && c > 0 && c <= 100 {
+		count = c
+	}
+
+	var pp []*post
+	for _, p := range s.sortedPosts() {
+		if len(pp) < count && p.hasTags(q["tag"]) {
+			pp = append(pp, p)
+		}
+	}
+	s.writePosts(rw, pp, false, s.updated)
+}
+
+func (s *Server) postsDates(rw responder, q url.Values) {
+	tag := q.Get("tag")
+	counts := map[string]int{}
+	var days []string
+	for _, p := range s.sortedPosts() {
+		if len(tag) > 0 && !p.hasTags([]string{tag}) {
+			continue
+		}
+		d := p.time.Format("2006-01-02")
+		if counts[d] == 0 {
+			days = append(days, d)
+		}
+		counts[d]++
+	}
+
+	type xmlDate struct {
+		Date  string `xml:"date,attr"`
+		Count int    `xml:"count,attr"`
+	}
+	xd := []xmlDate{}
+	jd := map[string]string{}
+	for _, d := range days {
+		xd = append(xd, xmlDate{Date: d, Count: counts[d]})
+		jd[d] = strconv.Itoa(counts[d])
+	}
+	rw.write(struct {
+		XMLName xml.Name  `xml:"dates"`
+		User    string    `xml:"user,attr"`
+		Tag     string    `xml:"tag,attr"`
+		Dates   []xmlDate `xml:"date"`
+	}{User: s.user, Tag: tag, Dates: xd}, map[string]interface{}{
+		"user":  s.user,
+		"tag":   tag,
+		"dates": jd,
+	})
+}
+
+func (s *Server) postsAll(rw responder, q url.Values) {
+	var from, to time.Time
+	if v := q.Get("fromdt"); len(v) > 0 {
+		from, _ = time.Parse(time.RFC3339, v)
+	}
+	if v := q.Get("todt"); len(v) > 0 {
+		to, _ = time.Parse(time.RFC3339, v)
+	}
+
+	var pp []*post
+	for _, p := range s.sortedPosts() {
+		if !from.IsZero() && p.time.Before(from) {
+			continue
+		}
+		if !to.IsZero() && p.time.After(to) {
+			continue
+		}
+		if p.hasTags(q["tag"]) {
+			pp = append(pp, p)
+		}
+	}
+
+	start, _ := strconv.Atoi(q.Get("start"))
+	if start > len(pp) {
+		start = len(pp)
+	}
+	pp = pp[start:]
+	if results, err := strconv.Atoi(q.Get("results")); err == nil && results >= 0 && results < len(pp) {
+		pp = pp[:results]
+	}
+
+	xp, jp := renderPosts(pp, q.Get("meta") == "yes")
+	rw.write(struct {
+		XMLName xml.Name  `xml:"posts"`
+		User    string    `xml:"user,attr"`
+		Posts   []xmlPost `xml:"post"`
+	}{User: s.user, Posts: xp}, jp)
+}
+
+func (s *Server) postsSuggest(rw responder, q url.Values) {
+	// The real API's popular tags come from other users, so only recommended
+	// tags (those already on the post) are suggested.
+	recommended := []string{}
+	if p, ok := s.posts[q.Get("url")]; ok {
+		recommended = append(recommended, p.tags...)
+	}
+	rw.write(struct {
+		XMLName     xml.Name `xml:"suggested"`
+		Popular     []string `xml:"popular"`
+		Recommended []string `xml:"recommended"`
+	}{Recommended: recommended}, []map[string][]string{
+		{"popular": {}},
+		{"recommended": recommended},
+	})
+}
+
+// tagCounts returns how many posts use each tag. Callers must hold s.mu.
+func (s *Server) tagCounts() map[string]int {
+	counts := map[string]int{}
+	for _, p := range s.posts {
+		for _, t := range p.tags {
+			counts[t]++
+		}
+	}
+	return counts
+}
+
+func (s *Server) tagsGet(rw responder) {
+	counts := s.tagCounts()
+	var names []string
+	for t := range counts {
+		names = append(names, t)
+	}
+	sort.Strings(names)
+
+	type xmlTag struct {
+		Count int    `xml:"count,attr"`
+		Tag   string `xml:"tag,attr"`
+	}
+	xt := []xmlTag{}
+	for _, t := range names {
+		xt = append(xt, xmlTag{Count: counts[t], Tag: t})
+	}
+	rw.write(struct {
+		XMLName xml.Name `xml:"tags"`
+		Tags    []xmlTag `xml:"tag"`
+	}{Tags: xt}, counts)
+}
+
+func (s *Server) tagsDelete(rw responder, q url.Values) {
+	tag := q.Get("tag")
+	changed := false
+	for _, p := range s.posts {
+		var tags []string
+		for _, t := range p.tags {
+			if strings.EqualFold(t, tag) {
+				changed = true
+				continue
+			}
+			tags = append(tags, t)
+		}
+		p.tags = tags
+	}
+	if changed {
+		s.touch()
+	}
+	rw.done()
+}
+
+func (s *Server) tagsRename(rw responder, q url.Values) {
+	old, new := q.Get("old"), q.Get("new")
+	if len(old) < 1 || len(new) < 1 {
+		rw.write(result{Result: "rename requires old and new tag"}, map[string]string{"result": "rename requires old and new tag"})
+		return
+	}
+
+	changed := false
+	for _, p := range s.posts {
+		var tags []string
+		seen := map[string]bool{}
+		for _, t := range p.tags {
+			if strings.EqualFold(t, old) {
+				t = new
+				changed = true
+			}
+			if !seen[t] {
+				tags = append(tags, t)
+				seen[t] = true
+			}
+		}
+		p.tags = tags
+	}
+	if changed {
+		s.touch()
+	}
+	rw.done()
+}
+
+type xmlNote struct {
+	XMLName xml.Name `xml:"note"`
+	ID      string   `xml:"id,attr"`
+	Title   string   `xml:"title"`
+	Hash    string   `xml:"hash"`
+	Created string   `xml:"created_at"`
+	Updated string   `xml:"updated_at"`
+	Length  int      `xml:"length"`
+	Text    string   `xml:"text,omitempty"`
+}
+
+type jsonNote struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Hash    string `json:"hash"`
+	Created string `json:"created_at"`
+	Updated string `json:"updated_at"`
+	Length  string `json:"length"`
+	Text    string `json:"text,omitempty"`
+}
+
+func renderNote(n *note, text bool) (xmlNote, jsonNote) {
+	const layout = "2006-01-02 15:04:05"
+	x := xmlNote{
+		ID:      n.id,
+		Title:   n.title,
+		Hash:    n.hash(),
+		Created: n.created.Format(layout),
+		Updated: n.updated.Format(layout),
+		Length:  len(n.text),
+	}
+	if text {
+		x.Text = n.text
+	}
+	return x, jsonNote{
+		ID:      x.ID,
+		Title:   x.Title,
+		Hash:    x.Hash,
+		Created: x.Created,
+		Updated: x.Updated,
+		Length:  strconv.Itoa(x.Length),
+		Text:    x.Text,
+	}
+}
+
+func (s *Server) notesList(rw responder) {
+	xn, jn := []xmlNote{}, []jsonNote{}
+	for _, n := range s.notes {
+		x, j := renderNote(n, false)
+		xn = append(xn, x)
+		jn = append(jn, j)
+	}
+	rw.write(struct {
+		XMLName xml.Name  `xml:"notes"`
+		Count   int       `xml:"count,attr"`
+		Notes   []xmlNote `xml:"note"`
+	}{Count: len(xn), Notes: xn}, map[string]interface{}{
+		"count": len(jn),
+		"notes": jn,
+	})
+}
+
+func (s *Server) notesGet(rw responder, id string) {
+	for _, n := range s.notes {
+		if n.id == id {
+			x, j := renderNote(n, true)
+			rw.write(x, j)
+			return
+		}
+	}
+	http.Error(rw.w, "Note not found", http.StatusNotFound)
+}
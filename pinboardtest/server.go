@@ -0,0 +1,284 @@
+// Package pinboardtest provides an in-memory fake of the Pinboard V1 API for
+// integration tests.
+//
+// A Server keeps a single account's posts and notes in memory and implements
+// every API method with the same parameters, validation, result codes and XML
+// or JSON responses as the real API, so code using the pinboard package can be
+// tested offline:
+//
+//	s := pinboardtest.NewServer()
+//	defer s.Close()
+//	p := s.Client()
+//	err := p.PostsAdd(pinboard.Post{Url: "https://example.com", Description: "Example"}, false)
+//
+// The package also provides a Recorder and a Replayer transport for capturing
+// requests made against the real API into cassette files and replaying them.
+package pinboardtest
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	pinboard "github.com/zoni/go-pinboard"
+)
+
+// Default credentials accepted by a Server.
+const (
+	DefaultUser  = "gopher"
+	DefaultToken = "0123456789ABCDEF0123"
+)
+
+// A Server is a fake Pinboard API listening on a local address.
+type Server struct {
+	// URL is the API root to pass to pinboard.WithBaseURL.
+	URL string
+
+	user     string
+	token    string
+	password string
+	secret   string
+	interval time.Duration
+
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	posts    map[string]*post
+	notes    []*note
+	updated  time.Time
+	lastCall time.Time
+	calls    map[string]int
+}
+
+// An Option configures a Server created by NewServer.
+type Option func(*Server)
+
+// WithToken sets the user and API token the server accepts.
+func WithToken(user, token string) Option {
+	return func(s *Server) {
+		s.user = user
+		s.token = token
+	}
+}
+
+// WithPassword makes the server also accept HTTP basic auth with the given
+// password.
+func WithPassword(password string) Option {
+	return func(s *Server) {
+		s.password = password
+	}
+}
+
+// WithRateLimit makes the server answer 429 Too Many Requests to calls made
+// less than interval after the previous one.
+func WithRateLimit(interval time.Duration) Option {
+	return func(s *Server) {
+		s.interval = interval
+	}
+}
+
+// WithPosts seeds the account with the given posts.
+func WithPosts(posts ...pinboard.Post) Option {
+	return func(s *Server) {
+		for _, pp := range posts {
+			s.AddPost(pp)
+		}
+	}
+}
+
+// NewServer starts and returns a new Server. The caller should call Close when
+// finished, to shut it down.
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		user:   DefaultUser,
+		token:  DefaultToken,
+		secret: "6493a84f72d86e7de130",
+		posts:  map[string]*post{},
+		calls:  map[string]int{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL + "/v1/"
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Client returns a pinboard client authenticated against the server. Additional
+// options are applied after the server's own.
+func (s *Server) Client(opts ...pinboard.Option) *pinboard.Pinboard {
+	opts = append([]pinboard.Option{
+		pinboard.WithToken(s.user, s.token),
+		pinboard.WithBaseURL(s.URL),
+		pinboard.WithHTTPClient(s.srv.Client()),
+	}, opts...)
+	return pinboard.New(opts...)
+}
+
+// AddPost stores pp in the account as if it had been added through the API,
+// replacing any post with the same URL. A zero Date is set to the current time.
+func (s *Server) AddPost(pp pinboard.Post) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pp.Date.IsZero() {
+		pp.Date = time.Now()
+	}
+	s.posts[pp.Url] = &post{
+		url:         pp.Url,
+		description: pp.Description,
+		extended:    pp.Extended,
+		tags:        append([]string(nil), pp.Tags...),
+		time:        pp.Date.UTC().Truncate(time.Second),
+		shared:      pp.Shared != pinboard.No,
+		toread:      pp.ToRead == pinboard.Yes,
+	}
+	s.touch()
+}
+
+// Posts returns every post in the account, most recent first.
+func (s *Server) Posts() []pinboard.Post {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pp []pinboard.Post
+	for _, p := range s.sortedPosts() {
+		pp = append(pp, p.post())
+	}
+	return pp
+}
+
+// AddNote stores a note in the account and returns its ID.
+func (s *Server) AddNote(title, text string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s\n%s\n%d", title, text, len(s.notes))))
+	n := &note{
+		id:      hex.EncodeToString(sum[:])[:20],
+		title:   title,
+		text:    text,
+		created: now,
+		updated: now,
+	}
+	s.notes = append(s.notes, n)
+	return n.id
+}
+
+// Calls returns how many requests the server has answered for the given API
+// method (ex: "posts/all"), including rejected ones.
+func (s *Server) Calls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+// touch records a change to the account. Callers must hold s.mu.
+func (s *Server) touch() {
+	now := time.Now().UTC().Truncate(time.Second)
+	if !now.After(s.updated) {
+		now = s.updated.Add(time.Second)
+	}
+	s.updated = now
+}
+
+// sortedPosts returns the account's posts, most recent first. Callers must hold
+// s.mu.
+func (s *Server) sortedPosts() []*post {
+	var pp []*post
+	for _, p := range s.posts {
+		pp = append(pp, p)
+	}
+	sort.Slice(pp, func(i, j int) bool {
+		if pp[i].time.Equal(pp[j].time) {
+			return pp[i].url < pp[j].url
+		}
+		return pp[i].time.After(pp[j].time)
+	})
+	return pp
+}
+
+type post struct {
+	url         string
+	description string
+	extended    string
+	tags        []string
+	time        time.Time
+	shared      bool
+	toread      bool
+}
+
+func (p *post) hash() string {
+	sum := md5.Sum([]byte(p.url))
+	return hex.EncodeToString(sum[:])
+}
+
+// meta changes whenever any of the post's fields change, like the real API's.
+func (p *post) meta() string {
+	sum := md5.Sum([]byte(fmt.Sprintf("%s\n%s\n%s\n%s\n%v\n%v\n%v", p.url, p.description, p.extended,
+		strings.Join(p.tags, " "), p.time.Unix(), p.shared, p.toread)))
+	return hex.EncodeToString(sum[:])
+}
+
+func (p *post) hasTags(tags []string) bool {
+	for _, t := range tags {
+		found := false
+		for _, pt := range p.tags {
+			if strings.EqualFold(t, pt) {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *post) post() pinboard.Post {
+	return pinboard.Post{
+		Url:         p.url,
+		Description: p.description,
+		Hash:        p.hash(),
+		Tags:        append([]string(nil), p.tags...),
+		Extended:    p.extended,
+		Date:        p.time,
+		Shared:      pinboard.NewYesNo(p.shared),
+		ToRead:      pinboard.NewYesNo(p.toread),
+		Meta:        p.meta(),
+	}
+}
+
+type note struct {
+	id      string
+	title   string
+	text    string
+	created time.Time
+	updated time.Time
+}
+
+func (n *note) hash() string {
+	sum := sha1.Sum([]byte(n.text))
+	return hex.EncodeToString(sum[:])[:20]
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,113 @@
+package pinboardtest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	pinboard "github.com/zoni/go-pinboard"
+)
+
+func TestServerPosts(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+
+	for _, opts := range [][]pinboard.Option{{}, {pinboard.WithJSON()}} {
+		p := s.Client(opts...)
+		pp := pinboard.Post{
+			Url:         "https://example.com/",
+			Description: "Example",
+			Extended:    "An example",
+			Tags:        []string{"example", ".private"},
+			Date:        time.Date(2011, time.March, 25, 14, 49, 56, 0, time.UTC),
+			Shared:      pinboard.No,
+			ToRead:      pinboard.Yes,
+		}
+		err := p.PostsAdd(pp, false)
+		if err != nil {
+			t.Fatalf("Error from PostsAdd: %v", err)
+		}
+		err = p.PostsAdd(pp, true)
+		if !errors.Is(err, pinboard.ErrItemExists) {
+			t.Errorf("Wanted ErrItemExists from PostsAdd, Got %v", err)
+		}
+
+		got, err := p.PostsGet(pinboard.PostsFilter{Url: pp.Url})
+		if err != nil {
+			t.Fatalf("Error from PostsGet: %v", err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("Wanted 1 post from PostsGet, Got %d", len(got))
+		}
+		if got[0].Description != pp.Description || got[0].Extended != pp.Extended ||
+			!reflect.DeepEqual([]string(got[0].Tags), []string(pp.Tags)) ||
+			!got[0].Date.Equal(pp.Date) || got[0].Shared != pinboard.No || got[0].ToRead != pinboard.Yes {
+			t.Errorf("Wanted %v, Got %v", pp, got[0])
+		}
+
+		err = p.TagsRename("example", "sample")
+		if err != nil {
+			t.Errorf("Error from TagsRename: %v", err)
+		}
+		tags, err := p.TagsGet()
+		if err != nil {
+			t.Errorf("Error from TagsGet: %v", err)
+		}
+		if len(tags) != 2 || tags[0].Tag != ".private" || tags[1].Tag != "sample" {
+			t.Errorf("Wanted tags .private and sample, Got %v", tags)
+		}
+
+		all, err := p.PostsAll(pinboard.PostsAllFilter{Tags: []string{"sample"}})
+		if err != nil || len(all) != 1 {
+			t.Errorf("Wanted 1 post from PostsAll, Got %v (%v)", all, err)
+		}
+
+		err = p.PostsDelete(pp.Url)
+		if err != nil {
+			t.Errorf("Error from PostsDelete: %v", err)
+		}
+		err = p.PostsDelete(pp.Url)
+		if !errors.Is(err, pinboard.ErrItemNotFound) {
+			t.Errorf("Wanted ErrItemNotFound from PostsDelete, Got %v", err)
+		}
+	}
+}
+
+func TestServerNotes(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	id := s.AddNote("Hello", "Hello, world")
+
+	p := s.Client()
+	notes, err := p.NotesList()
+	if err != nil || len(notes) != 1 || notes[0].ID != id {
+		t.Fatalf("Wanted note %s from NotesList, Got %v (%v)", id, notes, err)
+	}
+	note, err := p.NotesGet(id)
+	if err != nil || note.Text != "Hello, world" || note.Length != 12 {
+		t.Errorf("Wanted note text from NotesGet, Got %v (%v)", note, err)
+	}
+}
+
+func TestServerAuthAndRateLimit(t *testing.T) {
+	s := NewServer(WithRateLimit(time.Hour))
+	defer s.Close()
+
+	p := s.Client()
+	p.Token = "wrong"
+	_, err := p.UserSecret()
+	if !errors.Is(err, pinboard.ErrAuthFailed) {
+		t.Errorf("Wanted ErrAuthFailed, Got %v", err)
+	}
+
+	p = s.Client()
+	token, err := p.UserApiToken()
+	if err != nil || token != DefaultToken {
+		t.Errorf("Wanted token %s, Got %s (%v)", DefaultToken, token, err)
+	}
+	_, err = p.UserApiToken()
+	if !errors.Is(err, pinboard.ErrRateLimited) {
+		t.Errorf("Wanted ErrRateLimited, Got %v", err)
+	}
+}
@@ -1,9 +1,12 @@
 package pinboard
 
 import (
+	"context"
+	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"strings"
 	"time"
@@ -28,7 +31,9 @@ type posts struct {
 }
 
 // Posts returned by the Pinboard API. Methods will return a slice of []Post, there
-// are no single post read endpoint(s).
+// are no single post read endpoint(s). Shared is No for private posts, and ToRead
+// is Yes for posts marked as unread. Both are always set on posts read from the API
+// and unset on a new Post, leaving them to the account's defaults.
 type Post struct {
 	XMLName     xml.Name  `xml:"post" json:"-"`
 	Url         string    `xml:"href,attr"`
@@ -37,10 +42,22 @@ type Post struct {
 	Tags        postTags  `xml:"tag,attr"`
 	Extended    string    `xml:"extended,attr"`
 	Date        time.Time `xml:"time,attr"`
-	Shared      string    `xml:"shared,attr"`
+	Shared      YesNo     `xml:"shared,attr"`
+	ToRead      YesNo     `xml:"toread,attr"`
 	Meta        string    `xml:"meta,attr"`
 }
 
+// UnmarshalXML decodes a post element. The API only sends the toread attribute for
+// unread posts, so a missing one means ToRead is No.
+func (pp *Post) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type post Post
+	err := d.DecodeElement((*post)(pp), &start)
+	if err == nil && !pp.ToRead.IsSet() {
+		pp.ToRead = No
+	}
+	return err
+}
+
 type postsLastUpdate struct {
 	XMLName    xml.Name  `xml:"update" json:"-"`
 	UpdateTime time.Time `xml:"time,attr"`
@@ -66,16 +83,21 @@ type PostsRecentFilter struct {
 
 // PostsUpdated returns that datetime of the most recent post update.
 func (p *Pinboard) PostsUpdated() (time.Time, error) {
-	u, err := url.Parse(apiBase + "posts/update")
+	return p.PostsUpdatedContext(context.Background())
+}
+
+// PostsUpdatedContext is like PostsUpdated but uses ctx for the API request.
+func (p *Pinboard) PostsUpdatedContext(ctx context.Context) (time.Time, error) {
+	u, err := p.endpoint("posts/update")
 
-	resp, err := p.get(u)
+	resp, err := p.get(ctx, u)
 	if err != nil {
 		return time.Time{}, err
 	}
 
-	tmp, err := parseResponse(resp, &postsLastUpdate{})
+	tmp, err := p.parse(resp, &postsLastUpdate{})
 	if err != nil {
-		return time.Time{}, fmt.Errorf("Error parsing PostsUpdated response: %v", err)
+		return time.Time{}, fmt.Errorf("Error parsing PostsUpdated response: %w", err)
 	}
 	up := tmp.(*postsLastUpdate)
 
@@ -83,19 +105,77 @@ func (p *Pinboard) PostsUpdated() (time.Time, error) {
 }
 
 // PostsAdd adds a new post. The 'keep' argument decides whether a post should be
-// updated or rejected if the Url has already been saved before. The 'read' argument
-// sets the read-indicator within Pinboard (highlighting the post until "Mark as read"
-// has been clicked)
-func (p *Pinboard) PostsAdd(pp Post, keep bool, toread bool) error {
-	u, err := url.Parse(apiBase + "posts/add")
+// updated or rejected if the Url has already been saved before. A rejected post
+// returns an error matching ErrItemExists. Shared and ToRead are sent when they are
+// set, so a post read from the API and added back keeps its privacy and
+// read-indicator (ToRead highlights the post until "Mark as read" has been
+// clicked), while unset fields leave them to the API's defaults.
+func (p *Pinboard) PostsAdd(pp Post, keep bool) error {
+	return p.PostsAddContext(context.Background(), pp, keep)
+}
+
+// PostsAddContext is like PostsAdd but uses ctx for the API request.
+func (p *Pinboard) PostsAddContext(ctx context.Context, pp Post, keep bool) error {
+	err := ValidatePost(pp)
+	if err != nil {
+		return err
+	}
+
+	u, err := p.endpoint("posts/add")
+	if err != nil {
+		return fmt.Errorf("Failed to parse PostsAdd API URL: %w", err)
+	}
 	q := u.Query()
 
+	q.Set("url", pp.Url)
+	q.Set("description", pp.Description)
+	if len(pp.Extended) > 0 {
+		q.Set("extended", pp.Extended)
+	}
+	if len(pp.Tags) > 0 {
+		q.Set("tags", strings.Join(pp.Tags, " "))
+	}
+
+	if !pp.Date.IsZero() {
+		q.Set("dt", pp.Date.UTC().Format(time.RFC3339))
+	}
+
+	if keep {
+		q.Set("replace", "no")
+	}
+
+	if pp.Shared.IsSet() {
+		q.Set("shared", pp.Shared.String())
+	}
+	if pp.ToRead.IsSet() {
+		q.Set("toread", pp.ToRead.String())
+	}
+
+	u.RawQuery = q.Encode()
+
+	resp, err := p.get(ctx, u)
+	if err != nil {
+		return fmt.Errorf("Error adding post: %w", err)
+	}
+
+	err = p.checkResult(resp, "posts/add")
+	if err != nil {
+		return fmt.Errorf("Error adding post: %w", err)
+	}
+
+	return nil
+}
+
+// ValidatePost checks pp the way PostsAdd does before sending it, so decorators
+// and tools can reject invalid posts without calling the API. The returned error
+// matches ErrValidation.
+func ValidatePost(pp Post) error {
 	if len(pp.Url) < 1 {
-		return fmt.Errorf("PostsAdd requires a URL")
+		return validationErrorf("PostsAdd requires a URL")
 	}
 	pu, err := url.Parse(pp.Url)
 	if err != nil {
-		return fmt.Errorf("Error parsing PostsAdd URL %v", err)
+		return validationErrorf("Error parsing PostsAdd URL %v", err)
 	}
 	validScheme := false
 	for _, v := range validSchemes {
@@ -104,78 +184,105 @@ func (p *Pinboard) PostsAdd(pp Post, keep bool, toread bool) error {
 		}
 	}
 	if !validScheme {
-		return fmt.Errorf("Invalid scheme %v for URL in Pinboard Post. Scheme must be one of %v", pu.Scheme, validSchemes)
+		return validationErrorf("Invalid scheme %v for URL in Pinboard Post. Scheme must be one of %v", pu.Scheme, validSchemes)
 	}
 
-	q.Set("url", pp.Url)
-
 	if len(pp.Description) < 1 || len(pp.Description) > 255 {
-		return fmt.Errorf("Pinboard URL descriptions must be between 1 and 255 characters long")
+		return validationErrorf("Pinboard URL descriptions must be between 1 and 255 characters long")
+	}
+	if len(pp.Extended) > 65536 {
+		return validationErrorf("Pinboard extended descriptions must be less than 65536 characters long")
+	}
+	if len(pp.Tags) > 100 {
+		return validationErrorf("Pinboard posts may only have up to 100 tags")
 	}
+	return nil
+}
 
-	q.Set("description", pp.Description)
+// PostsUpdate edits the post saved for pu. Pinboard has no edit endpoint, so the
+// current post is fetched, handed to fn for modification and added back in its
+// place; fields fn leaves alone (Extended, Date, Shared, ToRead, ...) are kept. An
+// error from fn aborts the update and is returned as is, and nothing is written if
+// fn makes no changes. Changing the post's Url is not allowed.
+//
+// The post's Meta signature is checked again right before writing, and if someone
+// else modified the post in the meantime the returned error matches ErrConflict. If
+// no post is saved for pu the returned error matches ErrItemNotFound.
+func (p *Pinboard) PostsUpdate(pu string, fn func(*Post) error) error {
+	return p.PostsUpdateContext(context.Background(), pu, fn)
+}
 
-	if len(pp.Extended) > 0 {
-		if len(pp.Extended) > 65536 {
-			return fmt.Errorf("Pinboard extended descriptions must be less than 65536 characters long")
-		}
-		q.Set("extended", pp.Extended)
+// PostsUpdateContext is like PostsUpdate but uses ctx for the API requests.
+func (p *Pinboard) PostsUpdateContext(ctx context.Context, pu string, fn func(*Post) error) error {
+	current, err := p.postsGetOne(ctx, pu)
+	if err != nil {
+		return fmt.Errorf("Error updating post: %w", err)
 	}
 
-	if len(pp.Tags) > 0 {
-		if len(pp.Tags) > 100 {
-			return fmt.Errorf("Pinboard posts may only have up to 100 tags")
-		}
-		q.Set("tags", strings.Join(pp.Tags, " "))
+	pp := current
+	pp.Tags = append(postTags(nil), current.Tags...)
+	err = fn(&pp)
+	if err != nil {
+		return err
 	}
-
-	if !pp.Date.IsZero() {
-		q.Set("dt", pp.Date.UTC().Format(time.RFC3339))
+	if pp.Url != current.Url {
+		return validationErrorf("PostsUpdate cannot change the URL of a post")
 	}
-
-	if keep {
-		q.Set("replace", "no")
+	if len(diffFields(current, pp)) == 0 {
+		return nil
 	}
 
-	if toread {
-		q.Set("toread", "yes")
+	latest, err := p.postsGetOne(ctx, pu)
+	if err != nil {
+		return fmt.Errorf("Error updating post: %w", err)
 	}
-
-	if len(pp.Shared) > 0 {
-		lshared := strings.ToLower(pp.Shared)
-		if lshared == "yes" || lshared == "no" {
-			q.Set("shared", lshared)
-		} else {
-			return fmt.Errorf("Shared must be either \"yes\" or \"no\"")
-		}
+	if latest.Meta != current.Meta {
+		return fmt.Errorf("Error updating post %s: %w", pu, ErrConflict)
 	}
 
-	u.RawQuery = q.Encode()
+	return p.PostsAddContext(ctx, pp, false)
+}
 
-	_, err = p.get(u)
+// postsGetOne returns the post saved for pu, including its Meta signature.
+func (p *Pinboard) postsGetOne(ctx context.Context, pu string) (Post, error) {
+	if len(pu) < 1 {
+		return Post{}, validationErrorf("PostsUpdate requires a URL")
+	}
+	pp, err := p.PostsGetContext(ctx, PostsFilter{Url: pu, Meta: true})
 	if err != nil {
-		return fmt.Errorf("Error adding post: %v", err)
+		return Post{}, err
 	}
-
-	return nil
+	if len(pp) < 1 {
+		return Post{}, fmt.Errorf("No post saved for %s: %w", pu, ErrItemNotFound)
+	}
+	return pp[0], nil
 }
 
-// PostsDelete deletes a post via a given URL. The API does not distinguish whether
-// a post with the given URL actually exists within an account, so an error is only
-// returned if something happens at the HTTP/application server level.
+// PostsDelete deletes a post via a given URL. If no post with the given URL exists
+// the returned error matches ErrItemNotFound.
 func (p *Pinboard) PostsDelete(du string) error {
-	u, err := url.Parse(apiBase + "posts/delete")
+	return p.PostsDeleteContext(context.Background(), du)
+}
+
+// PostsDeleteContext is like PostsDelete but uses ctx for the API request.
+func (p *Pinboard) PostsDeleteContext(ctx context.Context, du string) error {
+	u, err := p.endpoint("posts/delete")
 	if err != nil {
-		return fmt.Errorf("Unable to parse PostsDelete url %v", err)
+		return fmt.Errorf("Unable to parse PostsDelete url %w", err)
 	}
 
 	q := u.Query()
 	q.Set("url", du)
 	u.RawQuery = q.Encode()
 
-	_, err = p.get(u)
+	resp, err := p.get(ctx, u)
 	if err != nil {
-		return fmt.Errorf("Error from PostsDelete request %v", err)
+		return fmt.Errorf("Error from PostsDelete request %w", err)
+	}
+
+	err = p.checkResult(resp, "posts/delete")
+	if err != nil {
+		return fmt.Errorf("Error from PostsDelete request %w", err)
 	}
 
 	return nil
@@ -183,13 +290,18 @@ func (p *Pinboard) PostsDelete(du string) error {
 
 // PostsGet retrieves all posts from a given day or the single post for a given URL.
 func (p *Pinboard) PostsGet(pf PostsFilter) ([]Post, error) {
-	u, _ := url.Parse(apiBase + "posts/get")
+	return p.PostsGetContext(context.Background(), pf)
+}
+
+// PostsGetContext is like PostsGet but uses ctx for the API request.
+func (p *Pinboard) PostsGetContext(ctx context.Context, pf PostsFilter) ([]Post, error) {
+	u, _ := p.endpoint("posts/get")
 	q := u.Query()
 
 	// Filters
 	if len(pf.Tags) > 0 {
 		if len(pf.Tags) > 3 {
-			return nil, fmt.Errorf("PostsFilter cannot accept more than 3 tags")
+			return nil, validationErrorf("PostsFilter cannot accept more than 3 tags")
 		}
 		for _, t := range pf.Tags {
 			q.Add("tag", t)
@@ -210,14 +322,14 @@ func (p *Pinboard) PostsGet(pf PostsFilter) ([]Post, error) {
 	u.RawQuery = q.Encode()
 
 	// Get posts
-	resp, err := p.get(u)
+	resp, err := p.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
 
-	tmp, err := parseResponse(resp, &posts{})
+	tmp, err := p.parse(resp, &posts{})
 	if err != nil {
-		return nil, fmt.Errorf("Error parsing PostsGet response: %v", err)
+		return nil, fmt.Errorf("Error parsing PostsGet response: %w", err)
 	}
 	t := tmp.(*posts)
 
@@ -242,7 +354,12 @@ type PostDate struct {
 // given tag. Contrary to Pinboard's API documentation only a single tag is
 // accepted for filtering.
 func (p *Pinboard) PostsDates(tag string) ([]PostDate, error) {
-	u, err := url.Parse(apiBase + "posts/dates")
+	return p.PostsDatesContext(context.Background(), tag)
+}
+
+// PostsDatesContext is like PostsDates but uses ctx for the API request.
+func (p *Pinboard) PostsDatesContext(ctx context.Context, tag string) ([]PostDate, error) {
+	u, err := p.endpoint("posts/dates")
 	q := u.Query()
 
 	if len(tag) > 0 {
@@ -250,12 +367,12 @@ func (p *Pinboard) PostsDates(tag string) ([]PostDate, error) {
 	}
 	u.RawQuery = q.Encode()
 
-	resp, err := p.get(u)
+	resp, err := p.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
 
-	tmp, err := parseResponse(resp, &postDates{})
+	tmp, err := p.parse(resp, &postDates{})
 	if err != nil {
 		return []PostDate{}, err
 	}
@@ -266,20 +383,25 @@ func (p *Pinboard) PostsDates(tag string) ([]PostDate, error) {
 
 // PostsRecent returns up to the 100 most recent posts from a user's account.
 func (p *Pinboard) PostsRecent(rpf PostsRecentFilter) ([]Post, error) {
-	u, err := url.Parse(apiBase + "posts/recent")
+	return p.PostsRecentContext(context.Background(), rpf)
+}
+
+// PostsRecentContext is like PostsRecent but uses ctx for the API request.
+func (p *Pinboard) PostsRecentContext(ctx context.Context, rpf PostsRecentFilter) ([]Post, error) {
+	u, err := p.endpoint("posts/recent")
 
 	// Filters
 	q := u.Query()
 	if rpf.Count != 0 {
 		if rpf.Count < 0 || rpf.Count > 100 {
-			return nil, fmt.Errorf("PostsRecentFilter count must be between 0 and 100")
+			return nil, validationErrorf("PostsRecentFilter count must be between 0 and 100")
 		}
 		q.Set("count", fmt.Sprintf("%d", rpf.Count))
 	}
 
 	if len(rpf.Tags) > 0 {
 		if len(rpf.Tags) > 3 {
-			return nil, fmt.Errorf("PostsRecentFilter cannot accept more than 3 tags")
+			return nil, validationErrorf("PostsRecentFilter cannot accept more than 3 tags")
 		}
 		for _, t := range rpf.Tags {
 			q.Add("tag", t)
@@ -288,14 +410,14 @@ func (p *Pinboard) PostsRecent(rpf PostsRecentFilter) ([]Post, error) {
 	u.RawQuery = q.Encode()
 
 	// Get posts
-	resp, err := p.get(u)
+	resp, err := p.get(ctx, u)
 	if err != nil {
 		return nil, err
 	}
 
-	tmp, err := parseResponse(resp, &posts{})
+	tmp, err := p.parse(resp, &posts{})
 	if err != nil {
-		return []Post{}, fmt.Errorf("Error parsing PostsRecent response: %v", err)
+		return []Post{}, fmt.Errorf("Error parsing PostsRecent response: %w", err)
 	}
 	pd := tmp.(*posts)
 
@@ -319,13 +441,73 @@ type PostsAllFilter struct {
 
 // PostsAll returns all posts in a user's account filtered by a PostsAllFilter.
 func (p *Pinboard) PostsAll(apf PostsAllFilter) ([]Post, error) {
-	u, _ := url.Parse(apiBase + "posts/all")
+	return p.PostsAllContext(context.Background(), apf)
+}
+
+// PostsAllContext is like PostsAll but uses ctx for the API request.
+func (p *Pinboard) PostsAllContext(ctx context.Context, apf PostsAllFilter) ([]Post, error) {
+	u, err := p.postsAllURL(apf)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.get(ctx, u)
+	if err != nil {
+		return nil, fmt.Errorf("PostsAll failed to retrieve: %w", err)
+	}
+
+	tmp, err := p.parse(resp, &posts{})
+	if err != nil {
+		return []Post{}, err
+	}
+	pd := tmp.(*posts)
+
+	return pd.Posts, err
+}
+
+// PostsAllEach calls fn for every post returned by PostsAll, decoding the response
+// one post at a time instead of loading the whole account into memory. If fn returns
+// an error iteration stops and the error is returned, unless it is ErrStopIteration,
+// in which case PostsAllEach returns nil.
+func (p *Pinboard) PostsAllEach(apf PostsAllFilter, fn func(Post) error) error {
+	return p.PostsAllEachContext(context.Background(), apf, fn)
+}
+
+// PostsAllEachContext is like PostsAllEach but uses ctx for the API request.
+func (p *Pinboard) PostsAllEachContext(ctx context.Context, apf PostsAllFilter, fn func(Post) error) error {
+	u, err := p.postsAllURL(apf)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.get(ctx, u)
+	if err != nil {
+		return fmt.Errorf("PostsAll failed to retrieve: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if p.json {
+		err = eachJSONPost(resp.Body, fn)
+	} else {
+		err = eachXMLPost(resp.Body, fn)
+	}
+	if errors.Is(err, ErrStopIteration) {
+		return nil
+	}
+	return err
+}
+
+func (p *Pinboard) postsAllURL(apf PostsAllFilter) (*url.URL, error) {
+	u, err := p.endpoint("posts/all")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse PostsAll API URL: %w", err)
+	}
 	q := u.Query()
 
 	// Filters
 	if len(apf.Tags) > 0 {
 		if len(apf.Tags) > 3 {
-			return nil, errors.New("PostsAll can not accept more than 3 tags")
+			return nil, validationErrorf("PostsAll can not accept more than 3 tags")
 		}
 		for _, t := range apf.Tags {
 			q.Add("tag", t)
@@ -345,7 +527,7 @@ func (p *Pinboard) PostsAll(apf PostsAllFilter) ([]Post, error) {
 	}
 
 	if !apf.To.IsZero() {
-		q.Set("fromdt", apf.To.UTC().Format(time.RFC3339))
+		q.Set("todt", apf.To.UTC().Format(time.RFC3339))
 	}
 
 	if apf.Meta {
@@ -353,16 +535,55 @@ func (p *Pinboard) PostsAll(apf PostsAllFilter) ([]Post, error) {
 	}
 
 	u.RawQuery = q.Encode()
-	resp, err := p.get(u)
-	if err != nil {
-		return nil, fmt.Errorf("PostsAll failed to retrieve: %v", err)
+	return u, nil
+}
+
+func eachXMLPost(r io.Reader, fn func(Post) error) error {
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Error parsing PostsAll response: %w", err)
+		}
+
+		se, ok := tok.(xml.StartElement)
+		if !ok || se.Name.Local != "post" {
+			continue
+		}
+		var pp Post
+		err = dec.DecodeElement(&pp, &se)
+		if err != nil {
+			return fmt.Errorf("Error parsing PostsAll response: %w", err)
+		}
+		err = fn(pp)
+		if err != nil {
+			return err
+		}
 	}
+}
 
-	tmp, err := parseResponse(resp, &posts{})
+func eachJSONPost(r io.Reader, fn func(Post) error) error {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
 	if err != nil {
-		return []Post{}, err
+		return fmt.Errorf("Error parsing PostsAll response: %w", err)
 	}
-	pd := tmp.(*posts)
-
-	return pd.Posts, err
+	if tok != json.Delim('[') {
+		return fmt.Errorf("Error parsing PostsAll response: expected a JSON array, got %v", tok)
+	}
+	for dec.More() {
+		var jp jsonPost
+		err := dec.Decode(&jp)
+		if err != nil {
+			return fmt.Errorf("Error parsing PostsAll response: %w", err)
+		}
+		err = fn(jp.post())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
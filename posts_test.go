@@ -0,0 +1,232 @@
+package pinboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var postsAllResponses = [2]string{
+	`<posts user="drags">
+  <post href="https://example.com/1" description="One" hash="1" tag="a b" time="2011-03-25T14:49:56Z" />
+  <post href="https://example.com/2" description="Two" hash="2" tag="b" time="2011-03-24T14:49:56Z" />
+  <post href="https://example.com/3" description="Three" hash="3" tag="c" time="2011-03-23T14:49:56Z" />
+</posts>`,
+	`[{"href":"https://example.com/1","description":"One","hash":"1","tags":"a b","time":"2011-03-25T14:49:56Z"},
+{"href":"https://example.com/2","description":"Two","hash":"2","tags":"b","time":"2011-03-24T14:49:56Z"},
+{"href":"https://example.com/3","description":"Three","hash":"3","tags":"c","time":"2011-03-23T14:49:56Z"}]`,
+}
+
+func postsAllServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("format") == "json" {
+			fmt.Fprint(w, postsAllResponses[1])
+			return
+		}
+		fmt.Fprint(w, postsAllResponses[0])
+	}))
+}
+
+func TestPostsAllEach(t *testing.T) {
+	s := postsAllServer()
+	defer s.Close()
+
+	for _, opts := range [][]Option{{}, {WithJSON()}} {
+		p := New(append(opts, WithToken("drags", "AC1638B3E618FD194CA0"), WithBaseURL(s.URL))...)
+		want, err := p.PostsAll(PostsAllFilter{})
+		if err != nil {
+			t.Fatalf("Error from PostsAll: %v", err)
+		}
+
+		var got []Post
+		err = p.PostsAllEach(PostsAllFilter{}, func(pp Post) error {
+			got = append(got, pp)
+			return nil
+		})
+		if err != nil {
+			t.Errorf("Error from PostsAllEach: %v", err)
+		}
+		if len(got) != 3 || !reflect.DeepEqual(want, got) {
+			t.Errorf("Wanted %v, Got %v", want, got)
+		}
+
+		n := 0
+		err = p.PostsAllEach(PostsAllFilter{}, func(pp Post) error {
+			n++
+			if pp.Hash == "2" {
+				return ErrStopIteration
+			}
+			return nil
+		})
+		if err != nil {
+			t.Errorf("Error from stopped PostsAllEach: %v", err)
+		}
+		if n != 2 {
+			t.Errorf("Wanted iteration to stop after 2 posts, Got %d", n)
+		}
+
+		err = p.PostsAllEach(PostsAllFilter{}, func(pp Post) error {
+			return fmt.Errorf("stopping at %s: %w", pp.Url, ErrStopIteration)
+		})
+		if err != nil {
+			t.Errorf("Error from PostsAllEach stopped with a wrapped ErrStopIteration: %v", err)
+		}
+	}
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestEachJSONPostReadError(t *testing.T) {
+	err := eachJSONPost(errReader{context.Canceled}, func(Post) error { return nil })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Wanted an error matching context.Canceled, Got %v", err)
+	}
+	err = eachJSONPost(strings.NewReader(`{}`), func(Post) error { return nil })
+	if err == nil {
+		t.Errorf("Wanted an error for a response that isn't an array")
+	}
+}
+
+func TestPostsPager(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("start"))
+		results, _ := strconv.Atoi(r.URL.Query().Get("results"))
+		fmt.Fprint(w, `<posts user="drags">`)
+		for i := start; i < start+results && i < 5; i++ {
+			fmt.Fprintf(w, `<post href="https://example.com/%d" description="Post %d" hash="%d" />`, i, i, i)
+		}
+		fmt.Fprint(w, `</posts>`)
+	}))
+	defer s.Close()
+
+	p := New(WithToken("drags", "AC1638B3E618FD194CA0"), WithBaseURL(s.URL))
+	pg := NewPostsPager(p, PostsAllFilter{}, 2)
+	pg.Interval = time.Millisecond
+	var offsets []int
+	pg.Progress = func(offset, fetched int) {
+		offsets = append(offsets, offset)
+	}
+
+	var hashes []string
+	err := pg.Each(context.Background(), func(pp Post) error {
+		hashes = append(hashes, pp.Hash)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error from PostsPager: %v", err)
+	}
+	if want := []string{"0", "1", "2", "3", "4"}; !reflect.DeepEqual(want, hashes) {
+		t.Errorf("Wanted posts %v, Got %v", want, hashes)
+	}
+	if want := []int{2, 4, 5}; !reflect.DeepEqual(want, offsets) {
+		t.Errorf("Wanted progress %v, Got %v", want, offsets)
+	}
+
+	// Resume from an offset
+	pg = NewPostsPager(p, PostsAllFilter{Start: 4}, 2)
+	pp, err := pg.Next(context.Background())
+	if err != nil {
+		t.Fatalf("Error from PostsPager: %v", err)
+	}
+	if len(pp) != 1 || pp[0].Hash != "4" || !pg.Done() {
+		t.Errorf("Wanted a short final page with post 4, Got %v", pp)
+	}
+
+	// Stop in the middle of a page, then resume from the offset
+	pg = NewPostsPager(p, PostsAllFilter{}, 2)
+	pg.Interval = time.Millisecond
+	hashes = nil
+	err = pg.Each(context.Background(), func(pp Post) error {
+		if pp.Hash == "3" {
+			return ErrStopIteration
+		}
+		hashes = append(hashes, pp.Hash)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error from stopped PostsPager: %v", err)
+	}
+	if pg.Offset() != 3 || pg.Done() {
+		t.Fatalf("Wanted offset 3 after stopping on post 3, Got %d", pg.Offset())
+	}
+	pg = NewPostsPager(p, PostsAllFilter{Start: pg.Offset()}, 2)
+	pg.Interval = time.Millisecond
+	err = pg.Each(context.Background(), func(pp Post) error {
+		hashes = append(hashes, pp.Hash)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error from resumed PostsPager: %v", err)
+	}
+	if want := []string{"0", "1", "2", "3", "4"}; !reflect.DeepEqual(want, hashes) {
+		t.Errorf("Wanted posts %v after resuming, Got %v", want, hashes)
+	}
+}
+
+func TestPostsAddFlags(t *testing.T) {
+	var query url.Values
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		fmt.Fprint(w, `<result code="done" />`)
+	}))
+	defer s.Close()
+	p := New(WithToken("drags", "AC1638B3E618FD194CA0"), WithBaseURL(s.URL))
+
+	for _, tc := range []struct {
+		shared, toread YesNo
+		want           []string
+	}{
+		{0, 0, []string{"unsent", "unsent"}},
+		{No, Yes, []string{"no", "yes"}},
+		{Yes, No, []string{"yes", "no"}},
+	} {
+		err := p.PostsAdd(Post{Url: "https://example.com/", Description: "Example", Shared: tc.shared, ToRead: tc.toread}, false)
+		if err != nil {
+			t.Fatalf("Error from PostsAdd: %v", err)
+		}
+		var got []string
+		for _, k := range []string{"shared", "toread"} {
+			v, ok := query[k]
+			if !ok {
+				v = []string{"unsent"}
+			}
+			got = append(got, v...)
+		}
+		if !reflect.DeepEqual(tc.want, got) {
+			t.Errorf("Wanted shared and toread %q, Got %q", tc.want, got)
+		}
+	}
+}
+
+func TestPostsAllDateRange(t *testing.T) {
+	var query url.Values
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		fmt.Fprint(w, `<posts user="drags"></posts>`)
+	}))
+	defer s.Close()
+	p := New(WithToken("drags", "AC1638B3E618FD194CA0"), WithBaseURL(s.URL))
+
+	from := time.Date(2011, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2011, 4, 1, 0, 0, 0, 0, time.UTC)
+	_, err := p.PostsAll(PostsAllFilter{From: from, To: to})
+	if err != nil {
+		t.Fatalf("Error from PostsAll: %v", err)
+	}
+	if got := query.Get("fromdt"); got != "2011-03-01T00:00:00Z" {
+		t.Errorf("Wanted fromdt 2011-03-01T00:00:00Z, Got %q", got)
+	}
+	if got := query.Get("todt"); got != "2011-04-01T00:00:00Z" {
+		t.Errorf("Wanted todt 2011-04-01T00:00:00Z, Got %q", got)
+	}
+}
@@ -0,0 +1,113 @@
+package pinboard
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimit configures client-side rate limiting. Pinboard asks clients to make
+// at most one call every 3 seconds, one call to posts/recent per minute and one
+// call to posts/all every 5 minutes, and answers with 429 Too Many Requests when
+// they don't.
+type RateLimit struct {
+	// Interval is the minimum time between any two API calls. A zero Interval
+	// uses DefaultRateLimit.Interval.
+	Interval time.Duration
+
+	// Endpoints holds the minimum time between two calls to the same API
+	// method, keyed by method path (ex: "posts/all"). Entries are merged over
+	// DefaultRateLimit.Endpoints.
+	Endpoints map[string]time.Duration
+
+	// FailFast makes calls return an error instead of blocking until the limit
+	// allows them through.
+	FailFast bool
+}
+
+// DefaultRateLimit holds the limits documented by Pinboard.
+var DefaultRateLimit = RateLimit{
+	Interval: 3 * time.Second,
+	Endpoints: map[string]time.Duration{
+		"posts/all":    5 * time.Minute,
+		"posts/recent": time.Minute,
+	},
+}
+
+// WithRateLimit enables client-side rate limiting. The limiter is shared by all
+// goroutines (and copies) using the same Pinboard.
+func WithRateLimit(rl RateLimit) Option {
+	return func(p *Pinboard) {
+		p.limiter = newRateLimiter(rl)
+	}
+}
+
+type rateLimiter struct {
+	mu        sync.Mutex
+	interval  time.Duration
+	endpoints map[string]time.Duration
+	failFast  bool
+	last      time.Time
+	lastCall  map[string]time.Time
+}
+
+func newRateLimiter(rl RateLimit) *rateLimiter {
+	l := &rateLimiter{
+		interval:  rl.Interval,
+		endpoints: map[string]time.Duration{},
+		failFast:  rl.FailFast,
+		lastCall:  map[string]time.Time{},
+	}
+	if l.interval == 0 {
+		l.interval = DefaultRateLimit.Interval
+	}
+	for k, v := range DefaultRateLimit.Endpoints {
+		l.endpoints[k] = v
+	}
+	for k, v := range rl.Endpoints {
+		l.endpoints[k] = v
+	}
+	return l
+}
+
+// reserve records a call to endpoint if the limits allow it, otherwise it
+// returns how long the caller has to wait before trying again.
+func (l *rateLimiter) reserve(endpoint string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	next := l.last.Add(l.interval)
+	if t := l.lastCall[endpoint].Add(l.endpoints[endpoint]); t.After(next) {
+		next = t
+	}
+	if now.Before(next) {
+		return next.Sub(now)
+	}
+
+	l.last = now
+	l.lastCall[endpoint] = now
+	return 0
+}
+
+// wait blocks until a call to endpoint is allowed or ctx is done.
+func (l *rateLimiter) wait(ctx context.Context, endpoint string) error {
+	for {
+		d := l.reserve(endpoint)
+		if d == 0 {
+			return nil
+		}
+		if l.failFast {
+			return fmt.Errorf("%w: %s may not be called for another %v", ErrRateLimited, endpoint, d.Round(time.Millisecond))
+		}
+
+		t := time.NewTimer(d)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
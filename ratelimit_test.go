@@ -0,0 +1,56 @@
+package pinboard
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterFailFast(t *testing.T) {
+	l := newRateLimiter(RateLimit{
+		Interval:  time.Millisecond,
+		Endpoints: map[string]time.Duration{"posts/all": time.Hour},
+		FailFast:  true,
+	})
+	ctx := context.Background()
+
+	if err := l.wait(ctx, "posts/all"); err != nil {
+		t.Fatalf("First call was limited: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if err := l.wait(ctx, "posts/get"); err != nil {
+		t.Errorf("posts/get was limited by posts/all: %v", err)
+	}
+	if err := l.wait(ctx, "posts/all"); err == nil {
+		t.Error("Second posts/all call was not limited")
+	}
+}
+
+func TestRateLimiterBlocks(t *testing.T) {
+	l := newRateLimiter(RateLimit{Interval: 30 * time.Millisecond})
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.wait(ctx, "posts/get"); err != nil {
+			t.Fatalf("Error from wait: %v", err)
+		}
+	}
+	if d := time.Since(start); d < 60*time.Millisecond {
+		t.Errorf("Three calls took %v, wanted at least 60ms", d)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := l.wait(ctx, "posts/get"); err != context.Canceled {
+		t.Errorf("Wanted context.Canceled, Got %v", err)
+	}
+}
+
+func TestMethod(t *testing.T) {
+	p := New(WithBaseURL("http://pinboard.example/api/v1"))
+	u, _ := p.endpoint("posts/all")
+	if got := p.method(u); got != "posts/all" {
+		t.Errorf("Wanted posts/all, Got %s", got)
+	}
+}
@@ -0,0 +1,184 @@
+package pinboard
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// A PostLookup finds the current version of a post by URL. A local mirror of the
+// account (see package mirror) can be used as one to avoid a PostsGet request per
+// restored post. Restored posts keep their original dates, which an incremental
+// mirror sync may not pick up, so run a full sync of the mirror after a restore.
+type PostLookup interface {
+	Post(url string) (Post, bool)
+}
+
+// RestoreOptions configure Restore.
+type RestoreOptions struct {
+	// Lookup finds existing posts. If nil, PostsGet is called for every post.
+	Lookup PostLookup
+
+	// Checkpoint is the path of a file recording the URLs of restored posts.
+	// Posts listed in it are skipped, so a restore that was interrupted can be
+	// resumed by running it again with the same checkpoint.
+	Checkpoint string
+
+	// Interval is the minimum time between two API calls made by Restore. If
+	// zero, DefaultRateLimit.Interval is used.
+	Interval time.Duration
+
+	// Progress, if set, is called after every post with the number of posts
+	// processed so far.
+	Progress func(done, total int)
+}
+
+// A RestoreFailure is a post Restore failed to add.
+type RestoreFailure struct {
+	Post Post
+	Err  error
+}
+
+// A RestoreSummary reports what Restore did.
+type RestoreSummary struct {
+	// Added counts posts that were missing from the account.
+	Added int
+
+	// Updated counts posts that existed but differed from the restored version.
+	Updated int
+
+	// Skipped counts posts that were already identical in the account or were
+	// listed in the checkpoint.
+	Skipped int
+
+	// Failed holds the posts that could not be added.
+	Failed []RestoreFailure
+}
+
+// Restore adds posts, such as those read from a backup, to the account. Posts that
+// are missing or differ from the account are added with PostsAdd, replacing the
+// existing version and keeping their original Date. Identical posts are skipped.
+//
+// A post that fails to be added is recorded in the summary's Failed list and the
+// restore continues. Restore stops and returns an error when ctx is done, the
+// credentials are rejected, or the checkpoint can't be written.
+func Restore(ctx context.Context, c Client, posts []Post, opts RestoreOptions) (RestoreSummary, error) {
+	var sum RestoreSummary
+
+	done := map[string]bool{}
+	var checkpoint *os.File
+	if len(opts.Checkpoint) > 0 {
+		var err error
+		done, err = readCheckpoint(opts.Checkpoint)
+		if err != nil {
+			return sum, err
+		}
+		checkpoint, err = os.OpenFile(opts.Checkpoint, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		if err != nil {
+			return sum, fmt.Errorf("Failed to open restore checkpoint: %w", err)
+		}
+		defer checkpoint.Close()
+	}
+
+	limiter := newRateLimiter(RateLimit{Interval: opts.Interval})
+	for n, pp := range posts {
+		if done[pp.Url] {
+			sum.Skipped++
+		} else {
+			restored, err := restorePost(ctx, c, limiter, opts.Lookup, pp, &sum)
+			if err != nil {
+				return sum, err
+			}
+			if restored && checkpoint != nil {
+				_, err = fmt.Fprintln(checkpoint, pp.Url)
+				if err != nil {
+					return sum, fmt.Errorf("Failed to write restore checkpoint: %w", err)
+				}
+			}
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(n+1, len(posts))
+		}
+	}
+
+	return sum, nil
+}
+
+// restorePost restores a single post, recording the outcome in sum, and reports
+// whether the post is now in the account. Only errors that should stop the
+// restore are returned.
+func restorePost(ctx context.Context, c Client, limiter *rateLimiter, lookup PostLookup, pp Post, sum *RestoreSummary) (bool, error) {
+	fail := func(err error) (bool, error) {
+		if ctx.Err() != nil || errors.Is(err, ErrAuthFailed) {
+			return false, err
+		}
+		sum.Failed = append(sum.Failed, RestoreFailure{Post: pp, Err: err})
+		return false, nil
+	}
+
+	existing, found, err := lookupPost(ctx, c, limiter, lookup, pp.Url)
+	if err != nil {
+		return fail(err)
+	}
+	if found && len(diffFields(existing, pp)) == 0 {
+		sum.Skipped++
+		return true, nil
+	}
+
+	err = limiter.wait(ctx, "posts/add")
+	if err == nil {
+		err = c.PostsAddContext(ctx, pp, false)
+	}
+	if err != nil {
+		return fail(err)
+	}
+	if found {
+		sum.Updated++
+	} else {
+		sum.Added++
+	}
+	return true, nil
+}
+
+func lookupPost(ctx context.Context, c Client, limiter *rateLimiter, lookup PostLookup, u string) (Post, bool, error) {
+	if lookup != nil {
+		pp, found := lookup.Post(u)
+		return pp, found, nil
+	}
+
+	err := limiter.wait(ctx, "posts/get")
+	if err != nil {
+		return Post{}, false, err
+	}
+	existing, err := c.PostsGetContext(ctx, PostsFilter{Url: u})
+	if err != nil || len(existing) < 1 {
+		return Post{}, false, err
+	}
+	return existing[0], true, nil
+}
+
+func readCheckpoint(path string) (map[string]bool, error) {
+	done := map[string]bool{}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read restore checkpoint: %w", err)
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	s.Buffer(nil, 1<<20)
+	for s.Scan() {
+		done[s.Text()] = true
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("Failed to read restore checkpoint: %w", err)
+	}
+	return done, nil
+}
@@ -0,0 +1,60 @@
+package pinboard_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pinboard "github.com/zoni/go-pinboard"
+	"github.com/zoni/go-pinboard/pinboardtest"
+)
+
+func TestRestore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "restore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	checkpoint := filepath.Join(dir, "checkpoint")
+
+	date := time.Date(2011, time.March, 25, 14, 49, 56, 0, time.UTC)
+	same := pinboard.Post{Url: "https://example.com/same", Description: "Same", Tags: []string{"a"}, Date: date, Shared: pinboard.Yes}
+	changed := pinboard.Post{Url: "https://example.com/changed", Description: "Before", Date: date, Shared: pinboard.Yes}
+	s := pinboardtest.NewServer(pinboardtest.WithPosts(same, changed))
+	defer s.Close()
+
+	changed.Description = "After"
+	missing := pinboard.Post{Url: "https://example.com/missing", Description: "Missing", Date: date.Add(-time.Hour), Shared: pinboard.No}
+	invalid := pinboard.Post{Url: "gopher://example.com/", Description: "Invalid"}
+	posts := []pinboard.Post{same, changed, missing, invalid}
+
+	opts := pinboard.RestoreOptions{Checkpoint: checkpoint, Interval: time.Millisecond}
+	sum, err := pinboard.Restore(context.Background(), s.Client(), posts, opts)
+	if err != nil {
+		t.Fatalf("Error from Restore: %v", err)
+	}
+	if sum.Added != 1 || sum.Updated != 1 || sum.Skipped != 1 || len(sum.Failed) != 1 || sum.Failed[0].Post.Url != invalid.Url {
+		t.Errorf("Unexpected summary %+v", sum)
+	}
+
+	got, err := s.Client().PostsGet(pinboard.PostsFilter{Url: missing.Url})
+	if err != nil || len(got) != 1 || !got[0].Date.Equal(missing.Date) || got[0].Shared != pinboard.No {
+		t.Errorf("Wanted the missing post restored with its date, Got %v (%v)", got, err)
+	}
+
+	// Resuming skips everything in the checkpoint without looking it up
+	before := s.Calls("posts/get")
+	sum, err = pinboard.Restore(context.Background(), s.Client(), posts, opts)
+	if err != nil {
+		t.Fatalf("Error from resumed Restore: %v", err)
+	}
+	if sum.Skipped != 3 || len(sum.Failed) != 1 {
+		t.Errorf("Unexpected resumed summary %+v", sum)
+	}
+	if n := s.Calls("posts/get") - before; n != 1 {
+		t.Errorf("Wanted only the failed post looked up again, Got %d lookups", n)
+	}
+}
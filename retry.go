@@ -0,0 +1,86 @@
+package pinboard
+
+import (
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// A RetryPolicy decides how requests answered with 429 Too Many Requests or a 5xx
+// status are retried. Delays grow exponentially from BaseDelay up to MaxDelay, unless
+// the API sends a Retry-After header, which is honored instead.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the second attempt. It doubles for every
+	// attempt after that.
+	BaseDelay time.Duration
+
+	// MaxDelay caps the computed delay. Zero means no cap.
+	MaxDelay time.Duration
+
+	// Jitter randomizes each delay by up to this fraction of it (ex: 0.2 for
+	// +/- 20%) so clients sharing an account don't retry in lockstep.
+	Jitter float64
+
+	// RetryWrites enables retries for the write methods (posts/add,
+	// posts/delete, tags/delete and tags/rename). Reads are always retried.
+	RetryWrites bool
+}
+
+// DefaultRetryPolicy retries reads up to 3 times, starting 3 seconds apart to stay
+// within Pinboard's rate limit.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   3 * time.Second,
+	MaxDelay:    time.Minute,
+	Jitter:      0.2,
+}
+
+// WithRetry enables retrying failed requests according to rp.
+func WithRetry(rp RetryPolicy) Option {
+	return func(p *Pinboard) {
+		p.retry = &rp
+	}
+}
+
+// writeMethods are the API methods that change an account.
+var writeMethods = map[string]bool{
+	"posts/add":    true,
+	"posts/delete": true,
+	"tags/delete":  true,
+	"tags/rename":  true,
+}
+
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// delay returns how long to wait after the given (1-based) failed attempt.
+func (rp *RetryPolicy) delay(attempt int, retryAfter string) time.Duration {
+	if len(retryAfter) > 0 {
+		if s, err := strconv.Atoi(retryAfter); err == nil && s >= 0 {
+			return time.Duration(s) * time.Second
+		}
+		if t, err := http.ParseTime(retryAfter); err == nil {
+			if d := time.Until(t); d > 0 {
+				return d
+			}
+			return 0
+		}
+	}
+
+	d := rp.BaseDelay
+	for i := 1; i < attempt && (rp.MaxDelay == 0 || d < rp.MaxDelay); i++ {
+		d *= 2
+	}
+	if rp.MaxDelay > 0 && d > rp.MaxDelay {
+		d = rp.MaxDelay
+	}
+	if rp.Jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * rp.Jitter * float64(d))
+	}
+	return d
+}
@@ -0,0 +1,52 @@
+package pinboard
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	rp := RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second} {
+		if got := rp.delay(attempt, ""); got != want {
+			t.Errorf("Attempt %d: wanted %v, Got %v", attempt, want, got)
+		}
+	}
+	if got := rp.delay(1, "7"); got != 7*time.Second {
+		t.Errorf("Wanted Retry-After of 7s, Got %v", got)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	calls := map[string]int{}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls[r.URL.Path]++
+		if calls[r.URL.Path] < 3 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, `<update time="2011-03-24T19:02:07Z" />`)
+	}))
+	defer s.Close()
+
+	p := New(WithToken("drags", "AC1638B3E618FD194CA0"), WithBaseURL(s.URL), WithRetry(RetryPolicy{MaxAttempts: 3}))
+	if _, err := p.PostsUpdated(); err != nil {
+		t.Errorf("Error from PostsUpdated after retries: %v", err)
+	}
+	if calls["/posts/update"] != 3 {
+		t.Errorf("Wanted 3 calls to posts/update, Got %d", calls["/posts/update"])
+	}
+
+	err := p.PostsDelete("https://example.com")
+	if err == nil || !strings.Contains(err.Error(), "(429): slow down") {
+		t.Errorf("Wanted a 429 error from PostsDelete, Got %v", err)
+	}
+	if calls["/posts/delete"] != 1 {
+		t.Errorf("Wanted writes not to be retried, Got %d calls", calls["/posts/delete"])
+	}
+}
@@ -1,6 +1,7 @@
 package pinboard
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"net/url"
@@ -24,46 +25,79 @@ type Tag struct {
 
 // TagsGet returns a list of []Tag corresponding to the tags in the user's account.
 func (p *Pinboard) TagsGet() ([]Tag, error) {
-	u, err := url.Parse(apiBase + "tags/get")
+	return p.TagsGetContext(context.Background())
+}
+
+// TagsGetContext is like TagsGet but uses ctx for the API request.
+func (p *Pinboard) TagsGetContext(ctx context.Context) ([]Tag, error) {
+	u, err := p.endpoint("tags/get")
 	if err != nil {
-		return []Tag{}, fmt.Errorf("Failed to parse Tags API URL: %v", err)
+		return []Tag{}, fmt.Errorf("Failed to parse Tags API URL: %w", err)
 	}
 
-	resp, err := p.get(u)
+	resp, err := p.get(ctx, u)
 	if err != nil {
 		return []Tag{}, err
 	}
 
-	tmp, err := parseResponse(resp, &tags{})
+	tmp, err := p.parse(resp, &tags{})
 	if err != nil {
-		return []Tag{}, fmt.Errorf("Failed to parse Tags response %v", err)
+		return []Tag{}, fmt.Errorf("Failed to parse Tags response %w", err)
 	}
 	t := tmp.(*tags)
 
 	return t.Tags, err
 }
 
+// ValidateTag checks a tag the way TagsDelete does before sending it. The returned
+// error matches ErrValidation.
+func ValidateTag(tag string) error {
+	if len(tag) < 1 || len(tag) > 255 {
+		return validationErrorf("Tags must be between 1 and 255 characters in length")
+	}
+	return nil
+}
+
+// ValidateTagsRename checks the arguments of TagsRename the way it does before
+// sending them. The returned error matches ErrValidation.
+func ValidateTagsRename(old, new string) error {
+	if len(old) < 1 || len(new) < 1 {
+		return validationErrorf("Both old and new tag must not be empty string for TagsRename")
+	}
+	return nil
+}
+
 // TagsDelete deletes the given tag from a user's Pinboard account. There is no
 // central store for tags, they are simply removed from every post in a user's
-// account. This API endpoint has no meaningful response, so an error is returned
-// only if the HTTP request fails.
+// account.
 func (p *Pinboard) TagsDelete(tag string) error {
-	u, err := url.Parse(apiBase + "tags/delete")
+	return p.TagsDeleteContext(context.Background(), tag)
+}
+
+// TagsDeleteContext is like TagsDelete but uses ctx for the API request.
+func (p *Pinboard) TagsDeleteContext(ctx context.Context, tag string) error {
+	u, err := p.endpoint("tags/delete")
 	if err != nil {
-		return fmt.Errorf("Failed to parse TagsDelete API URL: %v", err)
+		return fmt.Errorf("Failed to parse TagsDelete API URL: %w", err)
 	}
 	q := u.Query()
 
-	if len(tag) < 1 || len(tag) > 255 {
-		return fmt.Errorf("Tags must be between 1 and 255 characters in length")
+	err = ValidateTag(tag)
+	if err != nil {
+		return err
 	}
 	q.Set("tag", tag)
 
 	u.RawQuery = q.Encode()
 
-	_, err = p.get(u)
+	resp, err := p.get(ctx, u)
 	if err != nil {
-		return fmt.Errorf("Error from TagsDelete request %v", err)
+		return fmt.Errorf("Error from TagsDelete request %w", err)
+	}
+
+	err = p.checkResult(resp, "tags/delete")
+	if err != nil {
+		return fmt.Errorf("Error from TagsDelete request %w", err)
 	}
 
 	return nil
@@ -71,14 +105,20 @@ func (p *Pinboard) TagsDelete(tag string) error {
 
 // TagsRename renames a tag by changing that tag on every post in the user's account.
 func (p *Pinboard) TagsRename(old, new string) error {
-	u, err := url.Parse(apiBase + "tags/rename")
+	return p.TagsRenameContext(context.Background(), old, new)
+}
+
+// TagsRenameContext is like TagsRename but uses ctx for the API request.
+func (p *Pinboard) TagsRenameContext(ctx context.Context, old, new string) error {
+	u, err := p.endpoint("tags/rename")
 	if err != nil {
-		return fmt.Errorf("Failed to parse TagsRename API URL: %v", err)
+		return fmt.Errorf("Failed to parse TagsRename API URL: %w", err)
 	}
 	q := u.Query()
 
-	if len(old) < 1 || len(new) < 1 {
-		return fmt.Errorf("Both old and new tag must not be empty string for TagsRename")
+	err = ValidateTagsRename(old, new)
+	if err != nil {
+		return err
 	}
 
 	q.Set("old", old)
@@ -86,9 +126,14 @@ func (p *Pinboard) TagsRename(old, new string) error {
 
 	u.RawQuery = q.Encode()
 
-	_, err = p.get(u)
+	resp, err := p.get(ctx, u)
 	if err != nil {
-		return fmt.Errorf("Error from TagsRename request %v", err)
+		return fmt.Errorf("Error from TagsRename request %w", err)
+	}
+
+	err = p.checkResult(resp, "tags/rename")
+	if err != nil {
+		return fmt.Errorf("Error from TagsRename request %w", err)
 	}
 
 	return nil
@@ -105,7 +150,12 @@ type TagSuggestions struct {
 // TagsSuggestions returns tag suggestions for the given URL. Note: Currently only recommended
 // tags are actually returned from the Pinboard API
 func (p *Pinboard) TagsSuggestions(postUrl string) (TagSuggestions, error) {
-	u, _ := url.Parse(apiBase + "posts/suggest")
+	return p.TagsSuggestionsContext(context.Background(), postUrl)
+}
+
+// TagsSuggestionsContext is like TagsSuggestions but uses ctx for the API request.
+func (p *Pinboard) TagsSuggestionsContext(ctx context.Context, postUrl string) (TagSuggestions, error) {
+	u, _ := p.endpoint("posts/suggest")
 	q := u.Query()
 
 	pu, _ := url.Parse(postUrl)
@@ -116,20 +166,20 @@ func (p *Pinboard) TagsSuggestions(postUrl string) (TagSuggestions, error) {
 		}
 	}
 	if !validScheme {
-		return TagSuggestions{}, fmt.Errorf("Invalid scheme for Pinboard URL. Scheme must be one of %v", validSchemes)
+		return TagSuggestions{}, validationErrorf("Invalid scheme for Pinboard URL. Scheme must be one of %v", validSchemes)
 	}
 
 	q.Set("url", postUrl)
 	u.RawQuery = q.Encode()
 
-	resp, err := p.get(u)
+	resp, err := p.get(ctx, u)
 	if err != nil {
 		return TagSuggestions{}, err
 	}
 
-	tmp, err := parseResponse(resp, &TagSuggestions{})
+	tmp, err := p.parse(resp, &TagSuggestions{})
 	if err != nil {
-		return TagSuggestions{}, fmt.Errorf("Failed to parse TagsSuggestions response %v", err)
+		return TagSuggestions{}, fmt.Errorf("Failed to parse TagsSuggestions response %w", err)
 	}
 	t := tmp.(*TagSuggestions)
 
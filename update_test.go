@@ -0,0 +1,67 @@
+package pinboard_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	pinboard "github.com/zoni/go-pinboard"
+	"github.com/zoni/go-pinboard/pinboardtest"
+)
+
+func TestPostsUpdate(t *testing.T) {
+	date := time.Date(2011, time.March, 25, 14, 49, 56, 0, time.UTC)
+	pp := pinboard.Post{
+		Url:         "https://example.com/",
+		Description: "Example",
+		Extended:    "An example",
+		Tags:        []string{"a", "b"},
+		Date:        date,
+		ToRead:      pinboard.Yes,
+	}
+	s := pinboardtest.NewServer(pinboardtest.WithPosts(pp))
+	defer s.Close()
+	p := s.Client()
+
+	err := p.PostsUpdate(pp.Url, func(pp *pinboard.Post) error {
+		pp.Tags = append(pp.Tags, "c")
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Error from PostsUpdate: %v", err)
+	}
+	got := s.Posts()
+	if len(got) != 1 {
+		t.Fatalf("Wanted 1 post, Got %d", len(got))
+	}
+	if !reflect.DeepEqual([]string(got[0].Tags), []string{"a", "b", "c"}) || got[0].Extended != pp.Extended ||
+		!got[0].Date.Equal(date) || got[0].Shared != pinboard.Yes || got[0].ToRead != pinboard.Yes {
+		t.Errorf("Wanted only tags to change, Got %v", got[0])
+	}
+
+	err = p.PostsUpdate(pp.Url, func(pp *pinboard.Post) error {
+		s.AddPost(pinboard.Post{Url: pp.Url, Description: "Changed elsewhere", Date: date})
+		pp.Description = "Changed here"
+		return nil
+	})
+	if !errors.Is(err, pinboard.ErrConflict) {
+		t.Errorf("Wanted ErrConflict from PostsUpdate, Got %v", err)
+	}
+	if got := s.Posts(); got[0].Description != "Changed elsewhere" {
+		t.Errorf("Wanted concurrent change to be kept, Got %v", got[0])
+	}
+
+	err = p.PostsUpdate("https://example.com/missing", func(pp *pinboard.Post) error { return nil })
+	if !errors.Is(err, pinboard.ErrItemNotFound) {
+		t.Errorf("Wanted ErrItemNotFound from PostsUpdate, Got %v", err)
+	}
+
+	err = p.PostsUpdate(pp.Url, func(pp *pinboard.Post) error {
+		pp.Url = "https://example.com/other"
+		return nil
+	})
+	if !errors.Is(err, pinboard.ErrValidation) {
+		t.Errorf("Wanted ErrValidation from PostsUpdate, Got %v", err)
+	}
+}
@@ -1,25 +1,30 @@
 package pinboard
 
 import (
+	"context"
 	"fmt"
-	"net/url"
 )
 
 // UserSecret returns the user's secret RSS key for viewing private feeds.
 func (p *Pinboard) UserSecret() (string, error) {
-	u, err := url.Parse(apiBase + "user/secret")
+	return p.UserSecretContext(context.Background())
+}
+
+// UserSecretContext is like UserSecret but uses ctx for the API request.
+func (p *Pinboard) UserSecretContext(ctx context.Context) (string, error) {
+	u, err := p.endpoint("user/secret")
 	if err != nil {
-		return "", fmt.Errorf("Failed to parse UserSecret url: %v", err)
+		return "", fmt.Errorf("Failed to parse UserSecret url: %w", err)
 	}
 
-	resp, err := p.get(u)
+	resp, err := p.get(ctx, u)
 	if err != nil {
-		return "", fmt.Errorf("Error from UserSecret request: %v", err)
+		return "", fmt.Errorf("Error from UserSecret request: %w", err)
 	}
 
-	tmp, err := parseResponse(resp, &result{})
+	tmp, err := p.parse(resp, &result{})
 	if err != nil {
-		return "", fmt.Errorf("Failed to parse UserSecret response %v", err)
+		return "", fmt.Errorf("Failed to parse UserSecret response %w", err)
 	}
 	res := tmp.(*result)
 	return res.Result, err
@@ -27,19 +32,24 @@ func (p *Pinboard) UserSecret() (string, error) {
 
 // UserApitoken returns the user's API token.
 func (p *Pinboard) UserApiToken() (string, error) {
-	u, err := url.Parse(apiBase + "user/api_token")
+	return p.UserApiTokenContext(context.Background())
+}
+
+// UserApiTokenContext is like UserApiToken but uses ctx for the API request.
+func (p *Pinboard) UserApiTokenContext(ctx context.Context) (string, error) {
+	u, err := p.endpoint("user/api_token")
 	if err != nil {
-		return "", fmt.Errorf("Failed to parse UserApiToken url: %v", err)
+		return "", fmt.Errorf("Failed to parse UserApiToken url: %w", err)
 	}
 
-	resp, err := p.get(u)
+	resp, err := p.get(ctx, u)
 	if err != nil {
-		return "", fmt.Errorf("Error from UserApiToken request: %v", err)
+		return "", fmt.Errorf("Error from UserApiToken request: %w", err)
 	}
 
-	tmp, err := parseResponse(resp, &result{})
+	tmp, err := p.parse(resp, &result{})
 	if err != nil {
-		return "", fmt.Errorf("Failed to parse UserApiToken response %v", err)
+		return "", fmt.Errorf("Failed to parse UserApiToken response %w", err)
 	}
 	res := tmp.(*result)
 	return res.Result, err
@@ -0,0 +1,261 @@
+package pinboard
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventType identifies the kind of change an Event describes.
+type EventType int
+
+const (
+	PostAdded EventType = iota + 1
+	PostChanged
+	PostDeleted
+	TagAppeared
+	TagVanished
+)
+
+func (t EventType) String() string {
+	switch t {
+	case PostAdded:
+		return "PostAdded"
+	case PostChanged:
+		return "PostChanged"
+	case PostDeleted:
+		return "PostDeleted"
+	case TagAppeared:
+		return "TagAppeared"
+	case TagVanished:
+		return "TagVanished"
+	}
+	return "EventType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// A FieldChange is a single changed field of a post, with both values formatted as
+// strings (tags space delimited, dates in RFC 3339).
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// An Event describes a single change to an account. Post is set for the post
+// events (to the deleted post for PostDeleted), Previous and Changes only for
+// PostChanged, and Tag only for the tag events.
+type Event struct {
+	Type     EventType
+	Post     Post
+	Previous Post
+	Changes  []FieldChange
+	Tag      string
+}
+
+// DiffPosts returns the events that turn the posts in from into the posts in to.
+// Posts are matched by Hash (or Url when Hash is empty) and considered changed
+// when their Meta or any of their fields differ. Post events come first, then tag
+// events, each in a stable order.
+func DiffPosts(from, to []Post) []Event {
+	old := map[string]Post{}
+	for _, pp := range from {
+		old[postKey(pp)] = pp
+	}
+
+	var events []Event
+	seen := map[string]bool{}
+	for _, pp := range to {
+		k := postKey(pp)
+		seen[k] = true
+		prev, ok := old[k]
+		if !ok {
+			events = append(events, Event{Type: PostAdded, Post: pp})
+			continue
+		}
+		changes := diffFields(prev, pp)
+		if len(changes) > 0 || (len(prev.Meta) > 0 && len(pp.Meta) > 0 && prev.Meta != pp.Meta) {
+			events = append(events, Event{Type: PostChanged, Post: pp, Previous: prev, Changes: changes})
+		}
+	}
+	for _, pp := range from {
+		if !seen[postKey(pp)] {
+			events = append(events, Event{Type: PostDeleted, Post: pp})
+		}
+	}
+
+	oldTags, newTags := tagSet(from), tagSet(to)
+	for _, t := range sortedKeys(newTags) {
+		if !oldTags[t] {
+			events = append(events, Event{Type: TagAppeared, Tag: t})
+		}
+	}
+	for _, t := range sortedKeys(oldTags) {
+		if !newTags[t] {
+			events = append(events, Event{Type: TagVanished, Tag: t})
+		}
+	}
+	return events
+}
+
+func postKey(pp Post) string {
+	if len(pp.Hash) > 0 {
+		return pp.Hash
+	}
+	return pp.Url
+}
+
+func diffFields(a, b Post) []FieldChange {
+	var changes []FieldChange
+	add := func(field, old, new string) {
+		if old != new {
+			changes = append(changes, FieldChange{Field: field, Old: old, New: new})
+		}
+	}
+	add("Url", a.Url, b.Url)
+	add("Description", a.Description, b.Description)
+	add("Extended", a.Extended, b.Extended)
+	add("Tags", strings.Join(a.Tags, " "), strings.Join(b.Tags, " "))
+	add("Date", formatDate(a.Date), formatDate(b.Date))
+	// An unset flag expresses no preference, so it doesn't count as a change
+	if a.Shared.IsSet() && b.Shared.IsSet() {
+		add("Shared", a.Shared.String(), b.Shared.String())
+	}
+	if a.ToRead.IsSet() && b.ToRead.IsSet() {
+		add("ToRead", a.ToRead.String(), b.ToRead.String())
+	}
+	return changes
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func tagSet(posts []Post) map[string]bool {
+	tags := map[string]bool{}
+	for _, pp := range posts {
+		for _, t := range pp.Tags {
+			tags[t] = true
+		}
+	}
+	return tags
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// A Watcher polls an account for changes and sends an Event on Events for every
+// post added, changed or deleted and every tag appearing or vanishing. It checks
+// posts/update every Interval and only downloads the account, with posts/all, when
+// the update time has moved, so Interval can be much shorter than the posts/all
+// rate limit as long as the account changes less often than that.
+type Watcher struct {
+	// Events receives the changes found by Run. It is unbuffered, so Run
+	// blocks until each event is received.
+	Events <-chan Event
+
+	// Interval is the time between two checks of posts/update.
+	Interval time.Duration
+
+	c       Client
+	events  chan Event
+	updated time.Time
+	posts   []Post
+	primed  bool
+
+	// pending holds the events of the last diff not received yet.
+	pending []Event
+}
+
+// NewWatcher returns a Watcher for the account c is authenticated as, checking it
+// every interval. An interval of zero or less is replaced by
+// DefaultRateLimit.Interval.
+func NewWatcher(c Client, interval time.Duration) *Watcher {
+	if interval <= 0 {
+		interval = DefaultRateLimit.Interval
+	}
+	events := make(chan Event)
+	return &Watcher{
+		Events:   events,
+		Interval: interval,
+		c:        c,
+		events:   events,
+	}
+}
+
+// Run polls the account until ctx is done or a request fails, returning the
+// error. The first poll records the account's current state without sending
+// events. The Watcher remembers its state, including events that were found but
+// not received yet, so Run can be called again after an error to resume watching
+// without repeating or losing events.
+func (w *Watcher) Run(ctx context.Context) error {
+	if w.Interval <= 0 {
+		return validationErrorf("Watcher Interval must be positive")
+	}
+	t := time.NewTicker(w.Interval)
+	defer t.Stop()
+
+	for {
+		err := w.poll(ctx)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func (w *Watcher) poll(ctx context.Context) error {
+	err := w.flush(ctx)
+	if err != nil {
+		return err
+	}
+
+	updated, err := w.c.PostsUpdatedContext(ctx)
+	if err != nil {
+		return err
+	}
+	if w.primed && !updated.After(w.updated) {
+		return nil
+	}
+
+	posts, err := w.c.PostsAllContext(ctx, PostsAllFilter{Meta: true})
+	if err != nil {
+		return err
+	}
+
+	if w.primed {
+		w.pending = DiffPosts(w.posts, posts)
+	}
+	w.updated = updated
+	w.posts = posts
+	w.primed = true
+	return w.flush(ctx)
+}
+
+// flush sends the pending events, dropping each one once it has been received.
+func (w *Watcher) flush(ctx context.Context) error {
+	for len(w.pending) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case w.events <- w.pending[0]:
+			w.pending = w.pending[1:]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package pinboard_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	pinboard "github.com/zoni/go-pinboard"
+	"github.com/zoni/go-pinboard/pinboardtest"
+)
+
+func TestDiffPosts(t *testing.T) {
+	date := time.Date(2011, time.March, 25, 14, 49, 56, 0, time.UTC)
+	from := []pinboard.Post{
+		{Url: "https://example.com/1", Hash: "1", Description: "One", Tags: []string{"a", "b"}, Date: date, Meta: "m1"},
+		{Url: "https://example.com/2", Hash: "2", Description: "Two", Tags: []string{"b"}, Date: date, Meta: "m2"},
+	}
+	to := []pinboard.Post{
+		{Url: "https://example.com/1", Hash: "1", Description: "Uno", Tags: []string{"a", "c"}, Date: date, Meta: "m3"},
+		{Url: "https://example.com/3", Hash: "3", Description: "Three", Tags: []string{"c"}, Date: date, Meta: "m4"},
+	}
+
+	var got []pinboard.EventType
+	for _, e := range pinboard.DiffPosts(from, to) {
+		got = append(got, e.Type)
+		if e.Type == pinboard.PostChanged {
+			want := []pinboard.FieldChange{
+				{Field: "Description", Old: "One", New: "Uno"},
+				{Field: "Tags", Old: "a b", New: "a c"},
+			}
+			if !reflect.DeepEqual(want, e.Changes) {
+				t.Errorf("Wanted changes %v, Got %v", want, e.Changes)
+			}
+		}
+		if e.Type == pinboard.TagAppeared && e.Tag != "c" || e.Type == pinboard.TagVanished && e.Tag != "b" {
+			t.Errorf("Unexpected tag in %v", e)
+		}
+	}
+	want := []pinboard.EventType{pinboard.PostChanged, pinboard.PostAdded, pinboard.PostDeleted, pinboard.TagAppeared, pinboard.TagVanished}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Wanted events %v, Got %v", want, got)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	s := pinboardtest.NewServer()
+	defer s.Close()
+	p := s.Client()
+
+	w := pinboard.NewWatcher(p, 10*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done := make(chan error)
+	go func() {
+		done <- w.Run(ctx)
+	}()
+
+	// Wait for the watcher to record the empty account first
+	for s.Calls("posts/all") < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	err := p.PostsAdd(pinboard.Post{Url: "https://example.com/", Description: "Example", Tags: []string{"new"}}, false)
+	if err != nil {
+		t.Fatalf("Error from PostsAdd: %v", err)
+	}
+
+	var got []pinboard.EventType
+	for len(got) < 2 {
+		select {
+		case e := <-w.Events:
+			got = append(got, e.Type)
+		case err := <-done:
+			t.Fatalf("Watcher stopped: %v", err)
+		}
+	}
+	want := []pinboard.EventType{pinboard.PostAdded, pinboard.TagAppeared}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Wanted events %v, Got %v", want, got)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Wanted context.Canceled from Run, Got %v", err)
+	}
+}
+
+func TestWatcherResume(t *testing.T) {
+	s := pinboardtest.NewServer()
+	defer s.Close()
+	p := s.Client()
+
+	w := pinboard.NewWatcher(p, 10*time.Millisecond)
+	run := func(ctx context.Context) chan error {
+		done := make(chan error, 1)
+		go func() {
+			done <- w.Run(ctx)
+		}()
+		return done
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := run(ctx)
+	for s.Calls("posts/all") < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	err := p.PostsAdd(pinboard.Post{Url: "https://example.com/", Description: "Example", Tags: []string{"new"}}, false)
+	if err != nil {
+		t.Fatalf("Error from PostsAdd: %v", err)
+	}
+
+	// Stop the watcher after the first of two events
+	got := []pinboard.EventType{(<-w.Events).Type}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("Wanted context.Canceled from Run, Got %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	done = run(ctx)
+	got = append(got, (<-w.Events).Type)
+	select {
+	case e := <-w.Events:
+		t.Errorf("Wanted no more events after resuming, Got %v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+	want := []pinboard.EventType{pinboard.PostAdded, pinboard.TagAppeared}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Wanted events %v, Got %v", want, got)
+	}
+	cancel()
+	<-done
+}
+
+func TestNewWatcherInterval(t *testing.T) {
+	w := pinboard.NewWatcher(nil, 0)
+	if w.Interval != pinboard.DefaultRateLimit.Interval {
+		t.Errorf("Wanted the default interval for 0, Got %v", w.Interval)
+	}
+}